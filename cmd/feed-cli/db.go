@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+// dbCommand groups database maintenance subcommands.
+var dbCommand = &cli.Command{
+	Name:  "db",
+	Usage: "Database maintenance",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "migrate",
+			Usage: "Apply pending schema migrations",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List pending migrations without applying them",
+				},
+			},
+			Action: migrateDB,
+		},
+		{
+			Name:      "backup",
+			Usage:     "Write a consistent copy of the database while it is in use",
+			ArgsUsage: "<path>",
+			Action:    backupDB,
+		},
+		{
+			Name:      "restore",
+			Usage:     "Replace the database with a backup and migrate it",
+			ArgsUsage: "<path>",
+			Action:    restoreDB,
+		},
+		{
+			Name:   "check",
+			Usage:  "Run integrity and foreign key checks and report orphaned rows",
+			Action: checkDB,
+		},
+		{
+			Name:   "vacuum",
+			Usage:  "Rebuild the database file to reclaim free space",
+			Action: vacuumDB,
+		},
+		{
+			Name:   "analyze",
+			Usage:  "Refresh query planner statistics",
+			Action: analyzeDB,
+		},
+	},
+}
+
+func migrateDB(c *cli.Context) error {
+	s, err := openStore(c, store.Open)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+
+	if c.Bool("dry-run") {
+		pending, err := s.PendingMigrations()
+		if err != nil {
+			return cli.Exit(err.Error(), ExitDataError)
+		}
+
+		return outputJSON(map[string]interface{}{
+			"dry_run":         true,
+			"current_version": version,
+			"latest_version":  store.LatestVersion(),
+			"pending":         pending,
+		})
+	}
+
+	applied, err := s.Migrate()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Migration failed: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success":          true,
+		"previous_version": version,
+		"current_version":  store.LatestVersion(),
+		"applied":          applied,
+	})
+}
+
+func backupDB(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli db backup <path>", ExitUsageError)
+	}
+
+	s, err := openStore(c, store.Open)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	result, err := s.Backup(c.Args().Get(0))
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+
+	return outputJSON(result)
+}
+
+func restoreDB(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli db restore <path>", ExitUsageError)
+	}
+
+	dbPath := c.String("db")
+	if store.IsPostgresDSN(dbPath) {
+		return cli.Exit(fmt.Sprintf("Restore failed: %v", store.ErrUnsupported), ExitDataError)
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return cli.Exit(fmt.Sprintf("failed to create database directory: %v", err), ExitDataError)
+	}
+
+	result, err := store.Restore(c.Args().Get(0), dbPath)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Restore failed: %v", err), ExitDataError)
+	}
+
+	return outputJSON(result)
+}
+
+func checkDB(c *cli.Context) error {
+	s, err := openStore(c, store.Open)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	result, err := s.Check()
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+
+	if err := outputJSON(result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return cli.Exit("", ExitDataError)
+	}
+	return nil
+}
+
+func vacuumDB(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	result, err := s.Vacuum()
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+
+	return outputJSON(result)
+}
+
+func analyzeDB(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	if err := s.Analyze(); err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types streamed by `update --events`.
+const (
+	EventFeedStarted     = "feed_started"
+	EventFeedNotModified = "feed_not_modified"
+	EventFeedDone        = "feed_done"
+	EventFeedFailed      = "feed_failed"
+	EventRunSummary      = "run_summary"
+)
+
+// updateEvent is a single NDJSON progress event.
+type updateEvent struct {
+	Event        string    `json:"event"`
+	Time         time.Time `json:"time"`
+	FeedID       int64     `json:"feed_id,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	NewEntries   *int      `json:"new_entries,omitempty"`
+	TotalEntries *int      `json:"total_entries,omitempty"`
+	Bytes        *int      `json:"bytes,omitempty"`
+	DurationMS   *int64    `json:"duration_ms,omitempty"`
+	Error        string    `json:"error,omitempty"`
+
+	// run_summary only
+	UpdatedFeeds    *int `json:"updated_feeds,omitempty"`
+	NotModified     *int `json:"not_modified,omitempty"`
+	Failed          *int `json:"failed,omitempty"`
+	Canceled        *int `json:"canceled,omitempty"`
+	TotalNewEntries *int `json:"total_new_entries,omitempty"`
+	Partial         bool `json:"partial,omitempty"`
+}
+
+// eventWriter streams events as newline-delimited JSON, one object per line.
+// A nil *eventWriter discards events, so callers don't need to check --events.
+type eventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newEventWriter(w io.Writer) *eventWriter {
+	return &eventWriter{enc: json.NewEncoder(w)}
+}
+
+// emit writes an event, stamping it with the current time.
+func (w *eventWriter) emit(e updateEvent) {
+	if w == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(e)
+}
+
+// intPtr and int64Ptr let zero counts still appear in event output.
+func intPtr(i int) *int {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
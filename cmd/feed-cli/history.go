@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+var historyCommand = &cli.Command{
+	Name:  "history",
+	Usage: "List entries by the time they were marked read, most recent first",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Only entries read within this duration (e.g., 7d, 24h)",
+		},
+		&cli.IntFlag{
+			Name:    "limit",
+			Aliases: []string{"n"},
+			Value:   50,
+			Usage:   "Maximum number of entries to show",
+		},
+		&cli.Int64SliceFlag{
+			Name:  "feed",
+			Usage: "Only entries from this feed ID (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:  "cursor",
+			Usage: "Resume after the page that returned this next_cursor",
+		},
+	},
+	Action: showHistory,
+	Subcommands: []*cli.Command{
+		{
+			Name:  "stats",
+			Usage: "Count entries read per day and per feed",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "since",
+					Value: "30d",
+					Usage: "Period to summarise (e.g., 7d, 4w)",
+				},
+			},
+			Action: showReadingStats,
+		},
+	},
+}
+
+func showHistory(c *cli.Context) error {
+	opts := store.QueryOptions{
+		Limit:   c.Int("limit"),
+		FeedIDs: c.Int64Slice("feed"),
+		Cursor:  c.String("cursor"),
+		Sort:    store.SortRead,
+	}
+	if since := c.String("since"); since != "" {
+		readSince, err := store.SinceToUnixTime(since)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Invalid query options: failed to parse --since flag: %v", err), ExitUsageError)
+		}
+		opts.ReadSince = &readSince
+	}
+	if err := opts.Validate(); err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid query options: %v", err), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	page, err := s.ListEntries(opts)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get entries: %v", err), ExitDataError)
+	}
+
+	var nextCursor interface{}
+	if page.NextCursor != "" {
+		nextCursor = page.NextCursor
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count":       len(page.Entries),
+		"total":       page.Total,
+		"next_cursor": nextCursor,
+		"entries":     page.Entries,
+	})
+}
+
+func showReadingStats(c *cli.Context) error {
+	since, err := store.SinceToUnixTime(c.String("since"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid --since: %v", err), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	stats, err := s.GetReadingStats(&since)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get reading stats: %v", err), ExitDataError)
+	}
+
+	return outputJSON(stats)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/robertmeta/feed-cli/feed"
+	"github.com/urfave/cli/v2"
+)
+
+// linkFlags configure how entry links are normalized when update and
+// backfill store new entries.
+var linkFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:    "strip-params",
+		Value:   cli.NewStringSlice(feed.DefaultStripParams...),
+		EnvVars: []string{"FEED_CLI_STRIP_PARAMS"},
+		Usage:   "Query parameters removed from entry links (comma-separated; a trailing * matches a prefix)",
+	},
+	&cli.BoolFlag{
+		Name:    "keep-redirects",
+		EnvVars: []string{"FEED_CLI_KEEP_REDIRECTS"},
+		Usage:   "Keep FeedBurner, Google News and similar redirect links instead of unwrapping them",
+	},
+	&cli.BoolFlag{
+		Name:    "raw-links",
+		EnvVars: []string{"FEED_CLI_RAW_LINKS"},
+		Usage:   "Store entry links exactly as the feed has them",
+	},
+}
+
+// linkNormalizer builds the link normalizer selected by linkFlags, or nil
+// for --raw-links.
+func linkNormalizer(c *cli.Context) *feed.LinkNormalizer {
+	if c.Bool("raw-links") {
+		return nil
+	}
+	return &feed.LinkNormalizer{
+		StripParams: c.StringSlice("strip-params"),
+		Unwrap:      !c.Bool("keep-redirects"),
+	}
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/robertmeta/feed-cli/feed"
 	"github.com/robertmeta/feed-cli/model"
@@ -20,6 +26,7 @@ const (
 	ExitGeneralError = 1
 	ExitUsageError   = 2
 	ExitDataError    = 3
+	ExitPartial      = 4 // Run was interrupted or timed out; results are incomplete
 )
 
 func main() {
@@ -32,9 +39,14 @@ func main() {
 				Name:    "db",
 				Aliases: []string{"d"},
 				Value:   getDefaultDBPath(),
-				Usage:   "Database file path",
+				Usage:   "Database file path, or a postgres:// URL",
 				EnvVars: []string{"FEED_CLI_DB"},
 			},
+			&cli.StringFlag{
+				Name:    "user",
+				Usage:   "User whose read, star and tag state to use (add one with 'user add')",
+				EnvVars: []string{"FEED_CLI_USER"},
+			},
 		},
 		Commands: []*cli.Command{
 			{
@@ -51,22 +63,74 @@ func main() {
 				Action: addFeed,
 			},
 			{
-				Name:   "feeds",
-				Usage:  "List all feeds",
+				Name:  "feeds",
+				Usage: "List all feeds",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "with-counts",
+						Usage: "Include entry counts, posting rate and fetch health",
+					},
+				},
 				Action: listFeeds,
 			},
+			{
+				Name:   "stats",
+				Usage:  "Show database-wide and per-feed statistics",
+				Action: showStats,
+			},
 			{
 				Name:  "update",
 				Usage: "Update feeds (fetch new entries)",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.Int64Flag{
 						Name:    "feed-id",
 						Aliases: []string{"f"},
 						Usage:   "Update specific feed by ID (if not set, updates all)",
 					},
-				},
+					&cli.BoolFlag{
+						Name:  "events",
+						Usage: "Stream progress as newline-delimited JSON events",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "Stop fetching after this long and report partial results (e.g., 60s, 5m)",
+					},
+				}, linkFlags...),
 				Action: updateFeeds,
 			},
+			{
+				Name:      "backfill",
+				Usage:     "Fetch historical entries from paged or archived feeds",
+				ArgsUsage: "<feed-id>",
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{
+						Name:  "max-pages",
+						Value: 10,
+						Usage: "Maximum number of pages to walk (0 = no limit)",
+					},
+					&cli.StringFlag{
+						Name:    "since",
+						Aliases: []string{"s"},
+						Usage:   "Stop at entries older than duration (e.g., 7d, 2w, 3m, 1y)",
+					},
+					&cli.BoolFlag{
+						Name:  "paged",
+						Usage: "Fall back to WordPress-style ?paged=N pagination",
+					},
+					&cli.BoolFlag{
+						Name:    "unread",
+						Aliases: []string{"u"},
+						Usage:   "Insert historical entries as unread (default: read)",
+					},
+				}, linkFlags...),
+				Action: backfillFeed,
+			},
+			{
+				Name:      "validate",
+				Usage:     "Validate a feed against RSS/Atom/JSON Feed rules",
+				ArgsUsage: "<url|file>",
+				Action:    validateFeed,
+			},
 			{
 				Name:  "list",
 				Usage: "List entries",
@@ -88,31 +152,146 @@ func main() {
 						Aliases: []string{"u"},
 						Usage:   "Show only unread entries",
 					},
+					&cli.BoolFlag{
+						Name:  "starred",
+						Usage: "Show only starred entries",
+					},
 					&cli.StringFlag{
 						Name:    "since",
 						Aliases: []string{"s"},
 						Usage:   "Show entries since duration (e.g., 7d, 2w, 3m, 1y)",
 					},
-					&cli.StringFlag{
+					&cli.StringSliceFlag{
 						Name:    "tag",
 						Aliases: []string{"t"},
-						Usage:   "Filter by tag",
+						Usage:   "Filter by tag (repeat or comma-separate for several)",
+					},
+					&cli.BoolFlag{
+						Name:  "all-tags",
+						Usage: "Require every --tag instead of any of them",
+					},
+					&cli.Int64SliceFlag{
+						Name:    "feed",
+						Aliases: []string{"f"},
+						Usage:   "Filter by feed ID (repeat or comma-separate for several)",
+					},
+					&cli.StringSliceFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "Filter by feed category (repeat or comma-separate for several)",
+					},
+					&cli.StringFlag{
+						Name:  "after",
+						Usage: "Show entries published at or after date (YYYY-MM-DD or RFC 3339)",
+					},
+					&cli.StringFlag{
+						Name:  "before",
+						Usage: "Show entries published before date (YYYY-MM-DD or RFC 3339)",
+					},
+					&cli.StringFlag{
+						Name:  "read",
+						Value: string(store.ReadAny),
+						Usage: "Filter by read state: read, unread or any",
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Value: string(store.SortPublished),
+						Usage: "Sort by: published, title, feed, id or read",
+					},
+					&cli.StringFlag{
+						Name:  "order",
+						Value: string(store.SortDesc),
+						Usage: "Sort direction: asc or desc",
+					},
+					&cli.StringFlag{
+						Name:  "cursor",
+						Usage: "Resume after a previous page (its next_cursor); use the same filters and sort",
+					},
+					&cli.StringSliceFlag{
+						Name:  "fields",
+						Usage: "Only output these entry fields, comma-separated (e.g., id,title,link)",
+					},
+					&cli.BoolFlag{
+						Name:  "with-content",
+						Usage: "Include each entry's HTML content",
+					},
+					&cli.BoolFlag{
+						Name:  "dedupe",
+						Usage: "Show one entry per group of duplicates across feeds",
+					},
+					&cli.StringFlag{
+						Name:  "view",
+						Usage: "Start from a saved view; other filter flags override it",
 					},
 				},
 				Action: listEntries,
 			},
+			{
+				Name:      "search",
+				Usage:     "Full-text search entries",
+				ArgsUsage: "<query>",
+				Description: `Searches entry titles, content and authors. Supports phrases ("go modules"),
+prefixes (sched*) and boolean operators (rust AND NOT crypto). Results are
+ranked by relevance (bm25) and include highlighted snippets.`,
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"l"},
+						Value:   50,
+						Usage:   "Maximum number of entries to return",
+					},
+					&cli.IntFlag{
+						Name:    "offset",
+						Aliases: []string{"o"},
+						Value:   0,
+						Usage:   "Offset for pagination",
+					},
+					&cli.BoolFlag{
+						Name:    "unread",
+						Aliases: []string{"u"},
+						Usage:   "Show only unread entries",
+					},
+					&cli.BoolFlag{
+						Name:  "starred",
+						Usage: "Show only starred entries",
+					},
+					&cli.StringFlag{
+						Name:    "since",
+						Aliases: []string{"s"},
+						Usage:   "Show entries since duration (e.g., 7d, 2w, 3m, 1y)",
+					},
+				},
+				Action: searchEntries,
+			},
 			{
 				Name:      "show",
-				Usage:     "Show entry details",
+				Usage:     "Show entry details, with your note and highlights",
 				ArgsUsage: "<entry-id>",
 				Action:    showEntry,
 			},
 			{
-				Name:      "mark-read",
-				Usage:     "Mark entries as read",
-				ArgsUsage: "<entry-id>...",
-				Action:    markRead,
+				Name:      "duplicates",
+				Usage:     "Show the entries other feeds carry for the same story",
+				ArgsUsage: "<entry-id>",
+				Action:    showDuplicates,
 			},
+			markReadCommand,
+			markUnreadCommand,
+			historyCommand,
+			undoCommand,
+			opsCommand,
+			userCommand,
+			usersCommand,
+			starCommand,
+			unstarCommand,
+			tagCommand,
+			untagCommand,
+			tagsCommand,
+			noteCommand,
+			notesCommand,
+			queueCommand,
+			viewCommand,
+			viewsCommand,
 			{
 				Name:   "mark-all-read",
 				Usage:  "Mark all entries as read",
@@ -124,6 +303,9 @@ func main() {
 				ArgsUsage: "<feed-id>",
 				Action:    removeFeed,
 			},
+			retentionCommand,
+			pruneCommand,
+			dbCommand,
 			{
 				Name:      "import",
 				Usage:     "Import feeds from OPML file",
@@ -142,6 +324,7 @@ func main() {
 				},
 				Action: exportOPML,
 			},
+			exportStarredCommand,
 		},
 	}
 
@@ -159,16 +342,39 @@ func getDefaultDBPath() string {
 	return filepath.Join(home, ".config", "feed-cli", "feed-cli.db")
 }
 
-func getStore(c *cli.Context) (*store.Store, error) {
+func getStore(c *cli.Context) (store.Storage, error) {
+	s, err := openStore(c, store.New)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := c.String("user"); name != "" {
+		if _, err := s.SetUser(name); err != nil {
+			s.Close()
+			if errors.Is(err, store.ErrUserNotFound) {
+				return nil, fmt.Errorf("%w (add it with 'feed-cli user add %s')", err, name)
+			}
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// openStore creates the database directory if needed and opens the database
+// with open (store.New migrates, store.Open does not). A postgres:// URL
+// opens a PostgreSQL database instead of a SQLite file.
+func openStore(c *cli.Context, open func(string) (*store.Store, error)) (store.Storage, error) {
 	dbPath := c.String("db")
 
 	// Create directory if it doesn't exist
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	if !store.IsPostgresDSN(dbPath) {
+		dir := filepath.Dir(dbPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
 	}
 
-	s, err := store.New(dbPath)
+	s, err := open(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -233,6 +439,14 @@ func listFeeds(c *cli.Context) error {
 	}
 	defer s.Close()
 
+	if c.Bool("with-counts") {
+		stats, err := s.GetFeedStats()
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to get feeds: %v", err), ExitDataError)
+		}
+		return outputJSON(stats)
+	}
+
 	feeds, err := s.GetAllFeeds()
 	if err != nil {
 		return cli.Exit(fmt.Sprintf("Failed to get feeds: %v", err), ExitDataError)
@@ -241,7 +455,41 @@ func listFeeds(c *cli.Context) error {
 	return outputJSON(feeds)
 }
 
+func showStats(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	totals, err := s.GetStats()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get stats: %v", err), ExitDataError)
+	}
+
+	feeds, err := s.GetFeedStats()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get feed stats: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"totals": totals,
+		"feeds":  feeds,
+	})
+}
+
 func updateFeeds(c *cli.Context) error {
+	// SIGINT/SIGTERM and --timeout cancel fetching; entries that were already
+	// fetched are still committed so the partial results are consistent.
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	writeCtx := context.WithoutCancel(ctx)
+
 	s, err := getStore(c)
 	if err != nil {
 		return cli.Exit(err.Error(), ExitDataError)
@@ -250,80 +498,317 @@ func updateFeeds(c *cli.Context) error {
 
 	feedID := c.Int64("feed-id")
 	fetcher := feed.NewFetcher()
+	fetcher.Links = linkNormalizer(c)
 
 	var feedsToUpdate []*model.Feed
 
 	if feedID > 0 {
 		// Update specific feed
-		f, err := s.GetFeed(feedID)
+		f, err := s.GetFeedContext(ctx, feedID)
 		if err != nil {
 			return cli.Exit(fmt.Sprintf("Failed to get feed: %v", err), ExitDataError)
 		}
 		feedsToUpdate = append(feedsToUpdate, f)
 	} else {
 		// Update all feeds
-		feedsToUpdate, err = s.GetAllFeeds()
+		feedsToUpdate, err = s.GetAllFeedsContext(ctx)
 		if err != nil {
 			return cli.Exit(fmt.Sprintf("Failed to get feeds: %v", err), ExitDataError)
 		}
 	}
 
+	var events *eventWriter
+	if c.Bool("events") {
+		events = newEventWriter(os.Stdout)
+	}
+
 	// Concurrent fetching with up to 50 parallel requests
 	results := make(map[string]interface{})
 	totalNewEntries := 0
+	notModified := 0
+	failed := 0
+	canceled := 0
+	runStart := time.Now()
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 50) // Limit to 50 concurrent fetches
 
+	// Fetches run in parallel; all database writes go through one goroutine
+	writer := s.NewWriter()
+
 	for _, f := range feedsToUpdate {
 		wg.Add(1)
 		go func(feedToUpdate *model.Feed) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }() // Release semaphore
+			// Acquire semaphore, giving up if the run is canceled while waiting
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }() // Release semaphore
+			case <-ctx.Done():
+			}
 
-			_, entries, err := fetcher.Fetch(feedToUpdate.URL)
-			if err != nil {
+			skip := func() {
 				mu.Lock()
+				canceled++
 				results[feedToUpdate.URL] = map[string]interface{}{
-					"error": err.Error(),
+					"feed_id":  feedToUpdate.ID,
+					"canceled": true,
 				}
 				mu.Unlock()
+			}
+
+			// Feeds not yet started when the run is canceled are skipped
+			if ctx.Err() != nil {
+				skip()
 				return
 			}
 
-			// Save entries
-			newEntries := 0
-			for _, entry := range entries {
-				entry.FeedID = feedToUpdate.ID
-				if err := s.SaveEntry(entry); err != nil {
-					// Ignore duplicate entries (already exists)
-					continue
+			start := time.Now()
+			events.emit(updateEvent{Event: EventFeedStarted, FeedID: feedToUpdate.ID, URL: feedToUpdate.URL})
+
+			fail := func(err error) {
+				mu.Lock()
+				failed++
+				results[feedToUpdate.URL] = map[string]interface{}{
+					"feed_id": feedToUpdate.ID,
+					"error":   err.Error(),
 				}
-				newEntries++
+				mu.Unlock()
+				events.emit(updateEvent{
+					Event:      EventFeedFailed,
+					FeedID:     feedToUpdate.ID,
+					URL:        feedToUpdate.URL,
+					Error:      err.Error(),
+					DurationMS: int64Ptr(time.Since(start).Milliseconds()),
+				})
+			}
+
+			fetched, err := fetcher.FetchConditionalContext(ctx, feedToUpdate.URL, feedToUpdate.ETag, feedToUpdate.LastModified)
+			if err != nil {
+				// Fetches cut short by cancellation say nothing about the feed
+				if ctx.Err() != nil {
+					skip()
+					return
+				}
+				fail(err)
+				writer.WriteError(writeCtx, feedToUpdate, err)
+				return
+			}
+
+			now := time.Now()
+			feedToUpdate.LastUpdated = &now
+			feedToUpdate.ETag = fetched.Feed.ETag
+			feedToUpdate.LastModified = fetched.Feed.LastModified
+
+			// Save entries through the single writer, one transaction per feed
+			newEntries, err := writer.Write(writeCtx, feedToUpdate, fetched.Entries)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			if !fetched.Modified {
+				mu.Lock()
+				notModified++
+				results[feedToUpdate.URL] = map[string]interface{}{
+					"feed_id":      feedToUpdate.ID,
+					"not_modified": true,
+				}
+				mu.Unlock()
+				events.emit(updateEvent{
+					Event:      EventFeedNotModified,
+					FeedID:     feedToUpdate.ID,
+					URL:        feedToUpdate.URL,
+					DurationMS: int64Ptr(time.Since(start).Milliseconds()),
+				})
+				return
 			}
 
 			mu.Lock()
 			totalNewEntries += newEntries
 			results[feedToUpdate.URL] = map[string]interface{}{
+				"feed_id":       feedToUpdate.ID,
 				"new_entries":   newEntries,
-				"total_entries": len(entries),
+				"total_entries": len(fetched.Entries),
 			}
 			mu.Unlock()
+			events.emit(updateEvent{
+				Event:        EventFeedDone,
+				FeedID:       feedToUpdate.ID,
+				URL:          feedToUpdate.URL,
+				NewEntries:   intPtr(newEntries),
+				TotalEntries: intPtr(len(fetched.Entries)),
+				Bytes:        intPtr(fetched.Bytes),
+				DurationMS:   int64Ptr(time.Since(start).Milliseconds()),
+			})
 		}(f)
 	}
 
 	// Wait for all goroutines to complete
 	wg.Wait()
+	writer.Close()
+
+	partial := ctx.Err() != nil
+
+	if events != nil {
+		events.emit(updateEvent{
+			Event:           EventRunSummary,
+			UpdatedFeeds:    intPtr(len(feedsToUpdate)),
+			NotModified:     intPtr(notModified),
+			Failed:          intPtr(failed),
+			Canceled:        intPtr(canceled),
+			TotalNewEntries: intPtr(totalNewEntries),
+			DurationMS:      int64Ptr(time.Since(runStart).Milliseconds()),
+			Partial:         partial,
+		})
+	} else {
+		err = outputJSON(map[string]interface{}{
+			"updated_feeds":     len(feedsToUpdate),
+			"total_new_entries": totalNewEntries,
+			"not_modified":      notModified,
+			"failed":            failed,
+			"canceled":          canceled,
+			"partial":           partial,
+			"results":           results,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	return outputJSON(map[string]interface{}{
-		"updated_feeds":     len(feedsToUpdate),
-		"total_new_entries": totalNewEntries,
-		"results":           results,
+	if partial {
+		return cli.Exit("", ExitPartial)
+	}
+	return nil
+}
+
+func backfillFeed(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.Exit("Usage: feed-cli backfill <feed-id>", ExitUsageError)
+	}
+
+	var feedID int64
+	if _, err := fmt.Sscanf(c.Args().Get(0), "%d", &feedID); err != nil {
+		return cli.Exit("Invalid feed ID", ExitUsageError)
+	}
+
+	var cutoff *time.Time
+	if since := c.String("since"); since != "" {
+		sinceUnix, err := store.SinceToUnixTime(since)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Invalid --since: %v", err), ExitUsageError)
+		}
+		t := time.Unix(sinceUnix, 0)
+		cutoff = &t
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	f, err := s.GetFeed(feedID)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get feed: %v", err), ExitDataError)
+	}
+
+	fetcher := feed.NewFetcher()
+	fetcher.Links = linkNormalizer(c)
+	opts := feed.ArchiveOptions{
+		MaxPages: c.Int("max-pages"),
+		Paged:    c.Bool("paged"),
+	}
+	markRead := !c.Bool("unread")
+
+	// SIGINT/SIGTERM stop the walk; pages already saved are kept
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	saved, newEntries, duplicates := 0, 0, 0
+	reachedCutoff := false
+
+	pages, err := fetcher.WalkArchiveContext(ctx, f.URL, opts, func(page *feed.Page) (bool, error) {
+		var entries []*model.Entry
+		for _, entry := range page.Entries {
+			if cutoff != nil && entry.Published.Before(*cutoff) {
+				reachedCutoff = true
+				continue
+			}
+			entry.IsRead = markRead
+			entries = append(entries, entry)
+		}
+
+		// One transaction per page, so pages already fetched survive a later
+		// failure; GUIDs already stored or pruned are skipped. History is
+		// read for every user, not only the current one
+		n, err := s.SaveHistory(f.ID, entries)
+		if err != nil {
+			return false, fmt.Errorf("failed to save entries: %w", err)
+		}
+		saved++
+		newEntries += n
+		duplicates += len(entries) - n
+
+		return !reachedCutoff, nil
 	})
+	partial := ctx.Err() != nil
+	if err != nil && !partial {
+		return cli.Exit(fmt.Sprintf("Backfill failed after %d pages (%d saved, %d new entries): %v", pages, saved, newEntries, err), ExitDataError)
+	}
+
+	err = outputJSON(map[string]interface{}{
+		"feed_id":        f.ID,
+		"pages_fetched":  pages,
+		"new_entries":    newEntries,
+		"duplicates":     duplicates,
+		"reached_cutoff": reachedCutoff,
+		"partial":        partial,
+	})
+	if err != nil {
+		return err
+	}
+
+	if partial {
+		return cli.Exit("", ExitPartial)
+	}
+	return nil
+}
+
+func validateFeed(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.Exit("Usage: feed-cli validate <url|file>", ExitUsageError)
+	}
+
+	source := c.Args().Get(0)
+	fetcher := feed.NewFetcher()
+
+	var report *feed.Report
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		r, err := fetcher.ValidateURLContext(c.Context, source)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to fetch feed: %v", err), ExitDataError)
+		}
+		report = r
+	} else {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to read feed file: %v", err), ExitDataError)
+		}
+		report = fetcher.Validate(source, content, "")
+	}
+
+	if err := outputJSON(report); err != nil {
+		return err
+	}
+
+	// Exit non-zero so scripts can detect broken feeds without parsing the report
+	if !report.Valid {
+		return cli.Exit("", ExitDataError)
+	}
+	return nil
 }
 
 func listEntries(c *cli.Context) error {
@@ -333,27 +818,142 @@ func listEntries(c *cli.Context) error {
 	}
 	defer s.Close()
 
+	// Flags given with --view override what the view saved
+	filter := store.ViewFilter{}
+	if name := c.String("view"); name != "" {
+		view, err := s.GetView(name)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to get view: %v", err), ExitDataError)
+		}
+		filter = view.Filter
+	}
+	applyFilterFlags(c, &filter)
+
+	opts, err := filter.QueryOptions()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid query options: %v", err), ExitUsageError)
+	}
+	if opts.Limit == 0 {
+		opts.Limit = c.Int("limit")
+	}
+	opts.Offset = c.Int("offset")
+	opts.Cursor = c.String("cursor")
+	opts.Fields = c.StringSlice("fields")
+	opts.WithContent = c.Bool("with-content")
+	if err := opts.Validate(); err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid query options: %v", err), ExitUsageError)
+	}
+
+	page, err := s.ListEntries(opts)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get entries: %v", err), ExitDataError)
+	}
+
+	var nextCursor interface{}
+	if page.NextCursor != "" {
+		nextCursor = page.NextCursor
+	}
+
+	var entries interface{} = page.Entries
+	if len(opts.Fields) > 0 {
+		if opts.WithContent {
+			opts.Fields = append(opts.Fields, "content")
+		}
+		entries = projectEntries(page.Entries, opts.Fields)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count":       len(page.Entries),
+		"total":       page.Total,
+		"limit":       opts.Limit,
+		"offset":      opts.Offset,
+		"next_cursor": nextCursor,
+		"entries":     entries,
+	})
+}
+
+// projectEntries returns the given fields of each entry, keyed by their JSON
+// names. Fields without a value are null rather than left out.
+func projectEntries(entries []*model.Entry, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		m := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			switch f {
+			case "id":
+				m[f] = e.ID
+			case "feed_id":
+				m[f] = e.FeedID
+			case "guid":
+				m[f] = e.GUID
+			case "title":
+				m[f] = e.Title
+			case "link":
+				m[f] = e.Link
+			case "original_link":
+				m[f] = e.OriginalLink
+			case "content":
+				m[f] = e.Content
+			case "author":
+				m[f] = e.Author
+			case "published":
+				m[f] = e.Published
+			case "is_read":
+				m[f] = e.IsRead
+			case "read_at":
+				m[f] = e.ReadAt
+			case "starred_at":
+				m[f] = e.StarredAt
+			case "tags":
+				if e.Tags == nil {
+					m[f] = []string{}
+				} else {
+					m[f] = e.Tags
+				}
+			case "group_id":
+				m[f] = e.GroupID
+			}
+		}
+		projected = append(projected, m)
+	}
+	return projected
+}
+
+func searchEntries(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.Exit("Usage: feed-cli search <query>", ExitUsageError)
+	}
+	query := strings.Join(c.Args().Slice(), " ")
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
 	opts, err := store.BuildQueryOptions(
 		c.Int("limit"),
 		c.Int("offset"),
 		c.Bool("unread"),
 		c.String("since"),
-		c.String("tag"),
+		"",
 	)
 	if err != nil {
 		return cli.Exit(fmt.Sprintf("Invalid query options: %v", err), ExitUsageError)
 	}
+	opts.StarredOnly = c.Bool("starred")
 
-	entries, err := s.GetEntries(opts)
+	results, err := s.Search(query, opts)
 	if err != nil {
-		return cli.Exit(fmt.Sprintf("Failed to get entries: %v", err), ExitDataError)
+		return cli.Exit(fmt.Sprintf("Search failed: %v", err), ExitDataError)
 	}
 
 	return outputJSON(map[string]interface{}{
-		"count":   len(entries),
+		"query":   query,
+		"count":   len(results),
 		"limit":   opts.Limit,
 		"offset":  opts.Offset,
-		"entries": entries,
+		"entries": results,
 	})
 }
 
@@ -382,9 +982,14 @@ func showEntry(c *cli.Context) error {
 	return outputJSON(entry)
 }
 
-func markRead(c *cli.Context) error {
+func showDuplicates(c *cli.Context) error {
 	if c.NArg() < 1 {
-		return cli.Exit("Usage: feed-cli mark-read <entry-id>...", ExitUsageError)
+		return cli.Exit("Usage: feed-cli duplicates <entry-id>", ExitUsageError)
+	}
+
+	var id int64
+	if _, err := fmt.Sscanf(c.Args().Get(0), "%d", &id); err != nil {
+		return cli.Exit("Invalid entry ID", ExitUsageError)
 	}
 
 	s, err := getStore(c)
@@ -393,21 +998,15 @@ func markRead(c *cli.Context) error {
 	}
 	defer s.Close()
 
-	marked := 0
-	for i := 0; i < c.NArg(); i++ {
-		var id int64
-		if _, err := fmt.Sscanf(c.Args().Get(i), "%d", &id); err != nil {
-			continue
-		}
-
-		if err := s.MarkEntryRead(id, true); err != nil {
-			continue
-		}
-		marked++
+	entries, err := s.GetDuplicates(id)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get duplicates: %v", err), ExitDataError)
 	}
 
 	return outputJSON(map[string]interface{}{
-		"marked_read": marked,
+		"entry_id":   id,
+		"count":      len(entries),
+		"duplicates": entries,
 	})
 }
 
@@ -417,21 +1016,16 @@ func markAllRead(c *cli.Context) error {
 		return cli.Exit(err.Error(), ExitDataError)
 	}
 	defer s.Close()
+	beginOperation(s)
 
-	// Get all unread entries
-	opts := store.QueryOptions{UnreadOnly: true}
-	entries, err := s.GetEntries(opts)
+	marked, err := s.MarkReadWhere(store.QueryOptions{}, true)
 	if err != nil {
-		return cli.Exit(fmt.Sprintf("Failed to get entries: %v", err), ExitDataError)
-	}
-
-	// Mark all as read
-	for _, entry := range entries {
-		s.MarkEntryRead(entry.ID, true)
+		return cli.Exit(fmt.Sprintf("Failed to update entries: %v", err), ExitDataError)
 	}
 
 	return outputJSON(map[string]interface{}{
-		"marked_read": len(entries),
+		"marked_read":  marked,
+		"operation_id": operationID(s),
 	})
 }
 
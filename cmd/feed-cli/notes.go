@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/robertmeta/feed-cli/feed"
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+var noteCommand = &cli.Command{
+	Name:      "note",
+	Usage:     "Write a note on an entry (in $EDITOR or from --stdin), or highlight part of it",
+	ArgsUsage: "<entry-id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "highlight",
+			Usage: "Add a highlight quoting this text instead of editing the note",
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Comment on the --highlight",
+		},
+		&cli.BoolFlag{
+			Name:  "stdin",
+			Usage: "Read the note from stdin instead of opening $EDITOR",
+		},
+		&cli.BoolFlag{
+			Name:  "delete",
+			Usage: "Delete the note",
+		},
+		&cli.Int64Flag{
+			Name:  "delete-highlight",
+			Usage: "Delete the highlight with this ID (no entry ID needed)",
+		},
+	},
+	Action: writeNote,
+}
+
+var notesCommand = &cli.Command{
+	Name:  "notes",
+	Usage: "List annotated entries with their notes and highlights",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "search",
+			Aliases: []string{"s"},
+			Usage:   "Only notes or highlights containing this text (case-insensitive)",
+		},
+		&cli.Int64SliceFlag{
+			Name:  "feed",
+			Usage: "Only entries from this feed ID (repeatable)",
+		},
+		&cli.IntFlag{
+			Name:    "limit",
+			Aliases: []string{"n"},
+			Usage:   "Maximum number of entries to show (0 = all)",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "json",
+			Usage:   "Output format: json or markdown",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output file for markdown (default: stdout)",
+		},
+	},
+	Action: listNotes,
+}
+
+func writeNote(c *cli.Context) error {
+	if c.IsSet("delete-highlight") {
+		if c.NArg() > 0 {
+			return cli.Exit("Usage: feed-cli note --delete-highlight <highlight-id>", ExitUsageError)
+		}
+		return deleteHighlight(c, c.Int64("delete-highlight"))
+	}
+
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli note [--stdin | --highlight <text> [--comment <text>] | --delete] <entry-id>", ExitUsageError)
+	}
+	id, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid entry ID: %s", c.Args().Get(0)), ExitUsageError)
+	}
+	if c.IsSet("comment") && !c.IsSet("highlight") {
+		return cli.Exit("--comment requires --highlight", ExitUsageError)
+	}
+	if c.Bool("delete") && c.IsSet("highlight") {
+		return cli.Exit("--delete and --highlight cannot be combined", ExitUsageError)
+	}
+	if c.Bool("stdin") && (c.Bool("delete") || c.IsSet("highlight")) {
+		return cli.Exit("--stdin cannot be combined with --delete or --highlight", ExitUsageError)
+	}
+	// Without a terminal there is no editor to open; reading stdin instead
+	// must be asked for, so a script never saves whatever stdin happens to be
+	if !c.Bool("stdin") && !c.Bool("delete") && !c.IsSet("highlight") && !stdinIsTerminal() {
+		return cli.Exit("stdin is not a terminal: pass --stdin to read the note from it", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	switch {
+	case c.Bool("delete"):
+		if err := s.DeleteNote(id); err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to delete note: %v", err), ExitDataError)
+		}
+		return outputJSON(map[string]interface{}{
+			"entry_id": id,
+			"deleted":  true,
+		})
+
+	case c.IsSet("highlight"):
+		h, err := s.AddHighlight(id, c.String("highlight"), c.String("comment"))
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to add highlight: %v", err), ExitDataError)
+		}
+		return outputJSON(h)
+	}
+
+	entry, err := s.GetEntry(id)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get entry: %v", err), ExitDataError)
+	}
+
+	var body string
+	if c.Bool("stdin") {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to read note: %v", err), ExitDataError)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return cli.Exit("No note text on stdin, nothing saved", ExitUsageError)
+		}
+		body = string(data)
+	} else {
+		current := ""
+		if entry.Note != nil {
+			current = entry.Note.Body + "\n"
+		}
+		if body, err = editText(current); err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to edit note: %v", err), ExitDataError)
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		return cli.Exit("Note is empty, nothing saved (use --delete to remove a note)", ExitUsageError)
+	}
+
+	note, err := s.SetNote(id, body)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to save note: %v", err), ExitDataError)
+	}
+	return outputJSON(map[string]interface{}{
+		"entry_id": id,
+		"note":     note,
+	})
+}
+
+func deleteHighlight(c *cli.Context, id int64) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	if err := s.DeleteHighlight(id); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to delete highlight: %v", err), ExitDataError)
+	}
+	return outputJSON(map[string]interface{}{
+		"highlight_id": id,
+		"deleted":      true,
+	})
+}
+
+// stdinIsTerminal reports whether stdin is a terminal, rather than a pipe, a
+// file or a device such as /dev/null.
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// editText opens text in $VISUAL or $EDITOR (default vi) and returns the
+// edited result.
+func editText(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	file, err := os.CreateTemp("", "feed-cli-note-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	// The editor setting may carry arguments, e.g. "code --wait"
+	args := append(strings.Fields(editor), file.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	return string(data), err
+}
+
+func listNotes(c *cli.Context) error {
+	format := c.String("format")
+	if format != "json" && format != "markdown" {
+		return cli.Exit(fmt.Sprintf("Unknown format %q (want json or markdown)", format), ExitUsageError)
+	}
+	if c.Int("limit") < 0 {
+		return cli.Exit("--limit must not be negative", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	entries, err := s.GetNotes(store.NoteOptions{
+		Search:  c.String("search"),
+		FeedIDs: c.Int64Slice("feed"),
+		Limit:   c.Int("limit"),
+	})
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get notes: %v", err), ExitDataError)
+	}
+
+	if format == "json" {
+		return outputJSON(map[string]interface{}{
+			"count":   len(entries),
+			"entries": entries,
+		})
+	}
+
+	outputPath := c.String("output")
+	var writer io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to create output file: %v", err), ExitDataError)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := feed.GenerateNotesMarkdown(writer, "feed-cli Notes", entries); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to export notes: %v", err), ExitDataError)
+	}
+
+	if outputPath != "" {
+		return outputJSON(map[string]interface{}{
+			"success": true,
+			"file":    outputPath,
+			"count":   len(entries),
+		})
+	}
+	return nil
+}
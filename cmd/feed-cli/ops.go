@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+var undoCommand = &cli.Command{
+	Name:      "undo",
+	Usage:     "Revert the last read/star operation, or the given one",
+	ArgsUsage: "[operation-id]",
+	Action:    undoOperation,
+}
+
+var opsCommand = &cli.Command{
+	Name:  "ops",
+	Usage: "List recent read/star operations",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "limit",
+			Aliases: []string{"n"},
+			Value:   20,
+			Usage:   "Maximum number of operations to show",
+		},
+	},
+	Action: listOperations,
+}
+
+// beginOperation groups the read/star changes of this invocation into one
+// journal operation, labelled with the command line.
+func beginOperation(s store.Storage) {
+	s.BeginOperation(operationLabel(os.Args[1:]))
+}
+
+// operationLabel joins command line arguments into an operation label, with
+// the password of any URL among them, such as a --db postgres:// URL,
+// redacted: labels are stored and shown by ops and undo.
+func operationLabel(args []string) string {
+	label := make([]string, len(args))
+	for i, arg := range args {
+		prefix, value := "", arg
+		if name, v, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(arg, "-") {
+			prefix, value = name+"=", v
+		}
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+			_, hasPassword := u.User.Password()
+			if q := u.Query(); q.Has("password") {
+				q.Set("password", "xxxxx")
+				u.RawQuery = q.Encode()
+				hasPassword = true
+			}
+			if hasPassword {
+				value = u.Redacted()
+			}
+		}
+		label[i] = prefix + value
+	}
+	return strings.Join(label, " ")
+}
+
+// operationID returns the journal operation of this invocation for JSON
+// output, or nil if nothing changed.
+func operationID(s store.Storage) interface{} {
+	if id := s.OperationID(); id != 0 {
+		return id
+	}
+	return nil
+}
+
+func undoOperation(c *cli.Context) error {
+	if c.NArg() > 1 {
+		return cli.Exit("Usage: feed-cli undo [operation-id]", ExitUsageError)
+	}
+
+	var id int64
+	if c.NArg() == 1 {
+		var err error
+		if id, err = strconv.ParseInt(c.Args().Get(0), 10, 64); err != nil || id <= 0 {
+			return cli.Exit(fmt.Sprintf("Invalid operation ID: %s", c.Args().Get(0)), ExitUsageError)
+		}
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	result, err := s.Undo(id)
+	if errors.Is(err, store.ErrNothingToUndo) {
+		return cli.Exit("Nothing to undo", ExitDataError)
+	}
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Undo failed: %v", err), ExitDataError)
+	}
+
+	return outputJSON(result)
+}
+
+func listOperations(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	ops, err := s.GetOperations(c.Int("limit"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get operations: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count":      len(ops),
+		"operations": ops,
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+// retentionFlags are shared by prune (global default) and retention (per feed).
+var retentionFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:    "keep",
+		Aliases: []string{"k"},
+		Usage:   "Keep only the newest N entries per feed",
+	},
+	&cli.StringFlag{
+		Name:  "max-age",
+		Usage: "Drop entries older than duration (e.g., 90d, 12w, 6m, 1y)",
+	},
+}
+
+var pruneCommand = &cli.Command{
+	Name:  "prune",
+	Usage: "Delete old entries according to retention policies",
+	Description: `--keep and --max-age set the default policy; feeds with their own policy
+(see "retention") override it. Unread entries are kept unless --force is
+given; starred entries are always kept. Pruned entries are remembered so
+later updates do not add them again.`,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Also delete unread entries",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Report what would be deleted without deleting",
+		},
+	}, retentionFlags...),
+	Action: pruneEntries,
+}
+
+var retentionCommand = &cli.Command{
+	Name:      "retention",
+	Usage:     "Set a feed's retention policy (no flags clears it)",
+	ArgsUsage: "<feed-id>",
+	Flags:     retentionFlags,
+	Action:    setRetention,
+}
+
+// retentionPolicy builds a policy from --keep and --max-age.
+func retentionPolicy(c *cli.Context) (store.RetentionPolicy, error) {
+	policy := store.RetentionPolicy{Keep: c.Int("keep")}
+	if policy.Keep < 0 {
+		return policy, fmt.Errorf("--keep must not be negative")
+	}
+
+	if maxAge := c.String("max-age"); maxAge != "" {
+		d, err := store.ParseDuration(maxAge)
+		if err != nil {
+			return policy, fmt.Errorf("failed to parse --max-age flag: %w", err)
+		}
+		policy.Days = int(d / (24 * time.Hour))
+		if policy.Days < 1 {
+			return policy, fmt.Errorf("--max-age must be at least one day")
+		}
+	}
+
+	return policy, nil
+}
+
+func pruneEntries(c *cli.Context) error {
+	policy, err := retentionPolicy(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	result, err := s.Prune(store.PruneOptions{
+		Default: policy,
+		Force:   c.Bool("force"),
+		DryRun:  c.Bool("dry-run"),
+	})
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to prune entries: %v", err), ExitDataError)
+	}
+
+	return outputJSON(result)
+}
+
+func setRetention(c *cli.Context) error {
+	// Flags must precede the feed ID; anything after it would be silently ignored
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli retention [--keep N] [--max-age D] <feed-id>", ExitUsageError)
+	}
+
+	feedID, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return cli.Exit("Invalid feed ID", ExitUsageError)
+	}
+
+	policy, err := retentionPolicy(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	if err := s.SetFeedRetention(feedID, policy); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to set retention: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success":   true,
+		"feed_id":   feedID,
+		"retention": policy,
+	})
+}
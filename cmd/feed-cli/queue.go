@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+)
+
+// queueCommand lists the read-later queue and groups its subcommands.
+var queueCommand = &cli.Command{
+	Name:   "queue",
+	Usage:  "Read-later queue, in your own order (lists it without a subcommand)",
+	Action: listQueue,
+	Flags:  queueListFlags,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "Add entries to the end of the queue",
+			ArgsUsage: "<entry-id>...",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "top",
+					Usage: "Add in front of the queue instead",
+				},
+			},
+			Action: queueAdd,
+		},
+		{
+			Name:   "list",
+			Usage:  "List queued entries in order",
+			Flags:  queueListFlags,
+			Action: listQueue,
+		},
+		{
+			Name:   "next",
+			Usage:  "Show the first queued entry",
+			Action: queueNext,
+		},
+		{
+			Name:      "move",
+			Usage:     "Move a queued entry to a position (1 is next)",
+			ArgsUsage: "<entry-id> <position>",
+			Action:    queueMove,
+		},
+		{
+			Name:      "done",
+			Usage:     "Mark entries read and remove them from the queue (default: the next one)",
+			ArgsUsage: "[<entry-id>...]",
+			Action:    queueDone,
+		},
+		{
+			Name:      "remove",
+			Usage:     "Remove entries from the queue without marking them read",
+			ArgsUsage: "<entry-id>...",
+			Action:    queueRemove,
+		},
+	},
+}
+
+var queueListFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:    "limit",
+		Aliases: []string{"n"},
+		Usage:   "Maximum number of entries to show (0 = all)",
+	},
+}
+
+// parseEntryIDs parses every argument as an entry ID.
+func parseEntryIDs(c *cli.Context) ([]int64, error) {
+	ids := make([]int64, 0, c.NArg())
+	for _, arg := range c.Args().Slice() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid entry ID: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func queueAdd(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.Exit("Usage: feed-cli queue add [--top] <entry-id>...", ExitUsageError)
+	}
+	ids, err := parseEntryIDs(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	added, err := s.QueueEntries(ids, c.Bool("top"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to queue entries: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"queued": added,
+	})
+}
+
+func listQueue(c *cli.Context) error {
+	if c.Int("limit") < 0 {
+		return cli.Exit("--limit must not be negative", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	items, err := s.GetQueue(c.Int("limit"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get queue: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count":   len(items),
+		"entries": items,
+	})
+}
+
+func queueNext(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	items, err := s.GetQueue(1)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get queue: %v", err), ExitDataError)
+	}
+	if len(items) == 0 {
+		return cli.Exit("Queue is empty", ExitDataError)
+	}
+
+	return outputJSON(items[0])
+}
+
+func queueMove(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("Usage: feed-cli queue move <entry-id> <position>", ExitUsageError)
+	}
+	id, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid entry ID: %s", c.Args().Get(0)), ExitUsageError)
+	}
+	position, err := strconv.Atoi(c.Args().Get(1))
+	if err != nil || position < 1 {
+		return cli.Exit(fmt.Sprintf("Invalid position: %s", c.Args().Get(1)), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	position, err = s.MoveQueueEntry(id, position)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to move entry: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"entry_id": id,
+		"position": position,
+	})
+}
+
+func queueDone(c *cli.Context) error {
+	ids, err := parseEntryIDs(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	if len(ids) == 0 {
+		items, err := s.GetQueue(1)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to get queue: %v", err), ExitDataError)
+		}
+		if len(items) == 0 {
+			return cli.Exit("Queue is empty", ExitDataError)
+		}
+		ids = []int64{items[0].ID}
+	}
+
+	beginOperation(s)
+	read, removed, err := s.FinishQueueEntries(ids)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to update queue: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"done":         removed,
+		"marked_read":  read,
+		"operation_id": operationID(s),
+	})
+}
+
+func queueRemove(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.Exit("Usage: feed-cli queue remove <entry-id>...", ExitUsageError)
+	}
+	ids, err := parseEntryIDs(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	removed, err := s.DequeueEntries(ids)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to update queue: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"removed": removed,
+	})
+}
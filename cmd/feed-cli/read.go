@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+// markFilterFlags select entries for mark-read and mark-unread instead of IDs.
+var markFilterFlags = []cli.Flag{
+	&cli.Int64SliceFlag{
+		Name:  "feed",
+		Usage: "Entries from this feed ID (repeatable)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "category",
+		Usage: "Entries from feeds in this category (repeatable)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "tag",
+		Usage: "Entries with this tag (repeatable; any of them)",
+	},
+	&cli.BoolFlag{
+		Name:  "all-tags",
+		Usage: "Require every --tag instead of any of them",
+	},
+	&cli.StringFlag{
+		Name:  "before",
+		Usage: "Entries published before this long ago (e.g., 2d) or this date",
+	},
+	&cli.Int64Flag{
+		Name:  "older-than-id",
+		Usage: "Entries with an ID lower than this",
+	},
+	&cli.StringFlag{
+		Name:  "view",
+		Usage: "Entries in this saved view, whatever its limit; other filters narrow it",
+	},
+	&cli.BoolFlag{
+		Name:  "all",
+		Usage: "Every entry",
+	},
+}
+
+var markReadCommand = &cli.Command{
+	Name:      "mark-read",
+	Usage:     "Mark entries as read, by ID or by filter",
+	ArgsUsage: "<entry-id>...",
+	Flags:     markFilterFlags,
+	Action:    func(c *cli.Context) error { return markEntries(c, true) },
+}
+
+var markUnreadCommand = &cli.Command{
+	Name:      "mark-unread",
+	Usage:     "Mark entries as unread, by ID or by filter",
+	ArgsUsage: "<entry-id>...",
+	Flags:     markFilterFlags,
+	Action:    func(c *cli.Context) error { return markEntries(c, false) },
+}
+
+func markEntries(c *cli.Context, read bool) error {
+	opts, filtered, err := markFilter(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+	if filtered == (c.NArg() > 0) {
+		return cli.Exit(fmt.Sprintf("Usage: feed-cli %s <entry-id>... | --feed ID | --category C | --tag T | --before D | --older-than-id ID | --view V | --all", c.Command.Name), ExitUsageError)
+	}
+
+	ids := make([]int64, 0, c.NArg())
+	for _, arg := range c.Args().Slice() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Invalid entry ID: %s", arg), ExitUsageError)
+		}
+		ids = append(ids, id)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	if name := c.String("view"); name != "" {
+		if opts, err = viewMarkFilter(s, name, opts); err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to get view: %v", err), ExitDataError)
+		}
+	}
+	beginOperation(s)
+
+	var marked int
+	if filtered {
+		marked, err = s.MarkReadWhere(opts, read)
+	} else {
+		marked, err = s.MarkEntriesRead(ids, read)
+	}
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to update entries: %v", err), ExitDataError)
+	}
+
+	key := "marked_read"
+	if !read {
+		key = "marked_unread"
+	}
+	return outputJSON(map[string]interface{}{
+		key:            marked,
+		"operation_id": operationID(s),
+	})
+}
+
+// markFilter builds the filter from markFilterFlags and reports whether any
+// of them was given.
+func markFilter(c *cli.Context) (store.QueryOptions, bool, error) {
+	opts := store.QueryOptions{
+		FeedIDs:     c.Int64Slice("feed"),
+		Categories:  c.StringSlice("category"),
+		Tags:        c.StringSlice("tag"),
+		AllTags:     c.Bool("all-tags"),
+		OlderThanID: c.Int64("older-than-id"),
+	}
+
+	if before := c.String("before"); before != "" {
+		t, err := beforeTime(before)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Before = &t
+	}
+
+	filtered := c.Bool("all") || len(opts.FeedIDs) > 0 || len(opts.Categories) > 0 ||
+		len(opts.Tags) > 0 || opts.Before != nil || opts.OlderThanID > 0 || c.String("view") != ""
+	return opts, filtered, nil
+}
+
+// viewMarkFilter returns the filters of a saved view, replaced by those in
+// flags where given. The view's sort and limit do not apply to marking.
+func viewMarkFilter(s store.Storage, name string, flags store.QueryOptions) (store.QueryOptions, error) {
+	view, err := s.GetView(name)
+	if err != nil {
+		return flags, err
+	}
+	opts, err := view.Filter.QueryOptions()
+	if err != nil {
+		return flags, err
+	}
+
+	if len(flags.FeedIDs) > 0 {
+		opts.FeedIDs = flags.FeedIDs
+	}
+	if len(flags.Categories) > 0 {
+		opts.Categories = flags.Categories
+	}
+	if len(flags.Tags) > 0 {
+		opts.Tags = flags.Tags
+		opts.AllTags = flags.AllTags
+	}
+	if flags.Before != nil {
+		opts.Before = flags.Before
+	}
+	opts.OlderThanID = flags.OlderThanID
+	opts.Sort, opts.Order, opts.Limit = "", "", 0
+	return opts, nil
+}
+
+// beforeTime parses a --before value given as a duration ago or a date.
+func beforeTime(v string) (int64, error) {
+	if d, err := store.ParseDuration(v); err == nil {
+		return time.Now().Add(-d).Unix(), nil
+	}
+	t, err := store.ParseDate(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse --before flag: expected a duration (e.g., 2d) or a date: %s", v)
+	}
+	return t.Unix(), nil
+}
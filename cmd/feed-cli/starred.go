@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/robertmeta/feed-cli/feed"
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+var starCommand = &cli.Command{
+	Name:      "star",
+	Usage:     "Star entries so they are kept when their feed is removed or pruned",
+	ArgsUsage: "<entry-id>...",
+	Action:    func(c *cli.Context) error { return starEntries(c, true) },
+}
+
+var unstarCommand = &cli.Command{
+	Name:      "unstar",
+	Usage:     "Unstar entries",
+	ArgsUsage: "<entry-id>...",
+	Action:    func(c *cli.Context) error { return starEntries(c, false) },
+}
+
+var exportStarredCommand = &cli.Command{
+	Name:  "export-starred",
+	Usage: "Export starred entries as an RSS feed or browser bookmarks",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "rss",
+			Usage:   "Output format: rss or bookmarks",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output file (default: stdout)",
+		},
+	},
+	Action: exportStarred,
+}
+
+func starEntries(c *cli.Context, starred bool) error {
+	if c.NArg() < 1 {
+		return cli.Exit(fmt.Sprintf("Usage: feed-cli %s <entry-id>...", c.Command.Name), ExitUsageError)
+	}
+
+	ids := make([]int64, 0, c.NArg())
+	for _, arg := range c.Args().Slice() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Invalid entry ID: %s", arg), ExitUsageError)
+		}
+		ids = append(ids, id)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+	beginOperation(s)
+
+	changed, err := s.StarEntries(ids, starred)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to update entries: %v", err), ExitDataError)
+	}
+
+	key := "starred"
+	if !starred {
+		key = "unstarred"
+	}
+	return outputJSON(map[string]interface{}{
+		key:            changed,
+		"operation_id": operationID(s),
+	})
+}
+
+func exportStarred(c *cli.Context) error {
+	var generate func(io.Writer, string, []*model.Entry) error
+	switch c.String("format") {
+	case "rss":
+		generate = feed.GenerateRSS
+	case "bookmarks":
+		generate = feed.GenerateBookmarks
+	default:
+		return cli.Exit(fmt.Sprintf("Unknown format %q (want rss or bookmarks)", c.String("format")), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	entries, err := s.GetEntries(store.QueryOptions{StarredOnly: true, WithContent: true})
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get entries: %v", err), ExitDataError)
+	}
+
+	outputPath := c.String("output")
+	var writer io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to create output file: %v", err), ExitDataError)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := generate(writer, "feed-cli Starred Entries", entries); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to export starred entries: %v", err), ExitDataError)
+	}
+
+	if outputPath != "" {
+		return outputJSON(map[string]interface{}{
+			"success": true,
+			"file":    outputPath,
+			"count":   len(entries),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+)
+
+var tagCommand = &cli.Command{
+	Name:      "tag",
+	Usage:     "Tag entries",
+	ArgsUsage: "<entry-id>... <tag>",
+	Action:    tagEntries,
+}
+
+var untagCommand = &cli.Command{
+	Name:      "untag",
+	Usage:     "Remove a tag from entries",
+	ArgsUsage: "<entry-id>... <tag>",
+	Action:    untagEntries,
+}
+
+// tagsCommand lists tags and groups tag management subcommands.
+var tagsCommand = &cli.Command{
+	Name:   "tags",
+	Usage:  "List tags with entry counts",
+	Action: listTags,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "rename",
+			Usage:     "Rename a tag",
+			ArgsUsage: "<old> <new>",
+			Action:    renameTag,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Delete a tag and remove it from all entries",
+			ArgsUsage: "<tag>",
+			Action:    deleteTag,
+		},
+	},
+}
+
+// parseTagArgs splits "<entry-id>... <tag>" arguments.
+func parseTagArgs(c *cli.Context) ([]int64, string, error) {
+	if c.NArg() < 2 {
+		return nil, "", fmt.Errorf("Usage: feed-cli %s <entry-id>... <tag>", c.Command.Name)
+	}
+
+	args := c.Args().Slice()
+	ids := make([]int64, 0, len(args)-1)
+	for _, arg := range args[:len(args)-1] {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("Invalid entry ID: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, args[len(args)-1], nil
+}
+
+func tagEntries(c *cli.Context) error {
+	ids, tag, err := parseTagArgs(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	tagged, err := s.TagEntries(ids, tag)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to tag entries: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"tag":    tag,
+		"tagged": tagged,
+	})
+}
+
+func untagEntries(c *cli.Context) error {
+	ids, tag, err := parseTagArgs(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	untagged, err := s.UntagEntries(ids, tag)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to untag entries: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"tag":      tag,
+		"untagged": untagged,
+	})
+}
+
+func listTags(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	tags, err := s.GetTags()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get tags: %v", err), ExitDataError)
+	}
+
+	return outputJSON(tags)
+}
+
+func renameTag(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("Usage: feed-cli tags rename <old> <new>", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	oldName, newName := c.Args().Get(0), c.Args().Get(1)
+	if err := s.RenameTag(oldName, newName); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to rename tag: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success": true,
+		"old":     oldName,
+		"new":     newName,
+	})
+}
+
+func deleteTag(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli tags delete <tag>", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	name := c.Args().Get(0)
+	if err := s.DeleteTag(name); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to delete tag: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success": true,
+		"tag":     name,
+	})
+}
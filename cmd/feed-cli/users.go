@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+// userCommand groups the commands that manage users.
+var userCommand = &cli.Command{
+	Name:  "user",
+	Usage: "Add users with their own read, star and tag state",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "Add a user to select with --user",
+			ArgsUsage: "<name>",
+			Action:    addUser,
+		},
+	},
+}
+
+var usersCommand = &cli.Command{
+	Name:   "users",
+	Usage:  "List users with their own read, star and tag state",
+	Action: listUsers,
+}
+
+func listUsers(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	users, err := s.GetUsers()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get users: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count": len(users),
+		"users": users,
+	})
+}
+
+func addUser(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli user add <name>", ExitUsageError)
+	}
+
+	// Not getStore: --user may name the user being added
+	s, err := openStore(c, store.New)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	user, err := s.AddUser(c.Args().Get(0))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to add user: %v", err), ExitDataError)
+	}
+
+	return outputJSON(user)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertmeta/feed-cli/store"
+	"github.com/urfave/cli/v2"
+)
+
+// viewCommand groups the commands that manage saved views.
+var viewCommand = &cli.Command{
+	Name:  "view",
+	Usage: "Save, show and delete named list queries",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "save",
+			Usage:     "Save filters, sort and limit under a name (flags before the name)",
+			ArgsUsage: "<name>",
+			Flags:     viewFilterFlags,
+			Action:    saveView,
+		},
+		{
+			Name:      "show",
+			Usage:     "Show a view's saved filter",
+			ArgsUsage: "<name>",
+			Action:    showView,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Delete a view",
+			ArgsUsage: "<name>",
+			Action:    deleteView,
+		},
+	},
+}
+
+// viewsCommand lists saved views.
+var viewsCommand = &cli.Command{
+	Name:   "views",
+	Usage:  "List saved views with how many entries each matches",
+	Action: listViews,
+}
+
+// viewFilterFlags are the list filters a view can save; list takes the same
+// names, so applyFilterFlags reads either.
+var viewFilterFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "unread",
+		Aliases: []string{"u"},
+		Usage:   "Only unread entries",
+	},
+	&cli.StringFlag{
+		Name:  "read",
+		Usage: "Filter by read state: read, unread or any",
+	},
+	&cli.BoolFlag{
+		Name:  "starred",
+		Usage: "Only starred entries",
+	},
+	&cli.StringFlag{
+		Name:    "since",
+		Aliases: []string{"s"},
+		Usage:   "Entries since duration, counted from when the view is used (e.g., 7d, 2w)",
+	},
+	&cli.StringFlag{
+		Name:  "after",
+		Usage: "Entries published at or after date (YYYY-MM-DD or RFC 3339)",
+	},
+	&cli.StringFlag{
+		Name:  "before",
+		Usage: "Entries published before date (YYYY-MM-DD or RFC 3339)",
+	},
+	&cli.Int64SliceFlag{
+		Name:    "feed",
+		Aliases: []string{"f"},
+		Usage:   "Filter by feed ID (repeat or comma-separate for several)",
+	},
+	&cli.StringSliceFlag{
+		Name:    "category",
+		Aliases: []string{"c"},
+		Usage:   "Filter by feed category (repeat or comma-separate for several)",
+	},
+	&cli.StringSliceFlag{
+		Name:    "tag",
+		Aliases: []string{"t"},
+		Usage:   "Filter by tag (repeat or comma-separate for several)",
+	},
+	&cli.BoolFlag{
+		Name:  "all-tags",
+		Usage: "Require every --tag instead of any of them",
+	},
+	&cli.BoolFlag{
+		Name:  "dedupe",
+		Usage: "One entry per group of duplicates across feeds",
+	},
+	&cli.StringFlag{
+		Name:  "sort",
+		Usage: "Sort by: published, title, feed, id or read",
+	},
+	&cli.StringFlag{
+		Name:  "order",
+		Usage: "Sort direction: asc or desc",
+	},
+	&cli.IntFlag{
+		Name:    "limit",
+		Aliases: []string{"l"},
+		Usage:   "Maximum number of entries to list (0 = list's default)",
+	},
+}
+
+// applyFilterFlags overwrites the parts of f given as flags on the command
+// line, leaving the rest as saved.
+func applyFilterFlags(c *cli.Context, f *store.ViewFilter) {
+	if c.IsSet("unread") {
+		f.Unread = c.Bool("unread")
+	}
+	if c.IsSet("read") {
+		f.Read = store.ReadState(c.String("read"))
+	}
+	if c.IsSet("starred") {
+		f.Starred = c.Bool("starred")
+	}
+	if c.IsSet("since") {
+		f.Since = c.String("since")
+	}
+	if c.IsSet("after") {
+		f.After = c.String("after")
+	}
+	if c.IsSet("before") {
+		f.Before = c.String("before")
+	}
+	if c.IsSet("feed") {
+		f.FeedIDs = c.Int64Slice("feed")
+	}
+	if c.IsSet("category") {
+		f.Categories = c.StringSlice("category")
+	}
+	if c.IsSet("tag") {
+		f.Tags = c.StringSlice("tag")
+	}
+	if c.IsSet("all-tags") {
+		f.AllTags = c.Bool("all-tags")
+	}
+	if c.IsSet("dedupe") {
+		f.Dedupe = c.Bool("dedupe")
+	}
+	if c.IsSet("sort") {
+		f.Sort = store.SortField(c.String("sort"))
+	}
+	if c.IsSet("order") {
+		f.Order = store.SortOrder(c.String("order"))
+	}
+	if c.IsSet("limit") {
+		f.Limit = c.Int("limit")
+	}
+}
+
+func saveView(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli view save [filters] <name>", ExitUsageError)
+	}
+
+	filter := store.ViewFilter{}
+	applyFilterFlags(c, &filter)
+	if _, err := filter.QueryOptions(); err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid query options: %v", err), ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	view, err := s.SaveView(c.Args().Get(0), filter)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to save view: %v", err), ExitDataError)
+	}
+
+	return outputJSON(view)
+}
+
+func showView(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli view show <name>", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	view, err := s.GetView(c.Args().Get(0))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get view: %v", err), ExitDataError)
+	}
+
+	return outputJSON(view)
+}
+
+func deleteView(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("Usage: feed-cli view delete <name>", ExitUsageError)
+	}
+
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	name := c.Args().Get(0)
+	if err := s.DeleteView(name); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to delete view: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"success": true,
+		"view":    name,
+	})
+}
+
+func listViews(c *cli.Context) error {
+	s, err := getStore(c)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitDataError)
+	}
+	defer s.Close()
+
+	views, err := s.GetViews()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to get views: %v", err), ExitDataError)
+	}
+
+	return outputJSON(map[string]interface{}{
+		"count": len(views),
+		"views": views,
+	})
+}
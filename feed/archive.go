@@ -0,0 +1,228 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// Page is a single page of a paged (RFC 5005 section 3) or archived
+// (RFC 5005 section 4) feed.
+type Page struct {
+	URL     string
+	Feed    *model.Feed
+	Entries []*model.Entry
+	Next    string // URL of the next older page, empty if none was advertised
+}
+
+// ArchiveOptions controls how WalkArchive follows pagination links.
+type ArchiveOptions struct {
+	MaxPages int  // Stop after this many pages (0 = no limit)
+	Paged    bool // Fall back to WordPress-style ?paged=N when no link is advertised
+}
+
+// FetchPage retrieves a single feed page and discovers the link to the next older page.
+func (f *Fetcher) FetchPage(pageURL string) (*Page, error) {
+	return f.FetchPageContext(context.Background(), pageURL)
+}
+
+// FetchPageContext is like FetchPage but aborts the request when ctx is done.
+func (f *Fetcher) FetchPageContext(ctx context.Context, pageURL string) (*Page, error) {
+	body, _, err := f.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.ParsePage(string(body), pageURL)
+}
+
+// ParsePage parses a feed page. Relative pagination and entry links are
+// resolved against pageURL.
+func (f *Fetcher) ParsePage(content string, pageURL string) (*Page, error) {
+	feed, entries, err := f.parse(content, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		URL:     pageURL,
+		Feed:    feed,
+		Entries: entries,
+	}
+
+	if next := archiveLink(content); next != "" {
+		page.Next = resolveLink(pageURL, next)
+	}
+
+	return page, nil
+}
+
+// WalkArchive walks backwards through a feed's history starting at startURL,
+// calling visit for every page. Walking stops when visit returns false, when
+// no older page is available, or when opts.MaxPages pages have been visited.
+// A ?paged=N page that is missing (404 or 410) or empty also ends the walk,
+// as does a page identical to the previous one or holding only entries
+// already seen, which is how sites that ignore ?paged=N answer.
+// It returns the number of pages visited.
+func (f *Fetcher) WalkArchive(startURL string, opts ArchiveOptions, visit func(*Page) (bool, error)) (int, error) {
+	return f.WalkArchiveContext(context.Background(), startURL, opts, visit)
+}
+
+// WalkArchiveContext is like WalkArchive but stops with ctx's error when ctx
+// is done, aborting the request in flight.
+func (f *Fetcher) WalkArchiveContext(ctx context.Context, startURL string, opts ArchiveOptions, visit func(*Page) (bool, error)) (int, error) {
+	seen := make(map[string]bool)
+	seenGUIDs := make(map[string]bool)
+	var lastHash [sha256.Size]byte
+	pageURL := startURL
+	pages := 0
+	guessed := false // pageURL came from PagedURL rather than a link
+
+	for pageURL != "" && !seen[pageURL] {
+		if opts.MaxPages > 0 && pages >= opts.MaxPages {
+			break
+		}
+		seen[pageURL] = true
+
+		body, _, err := f.get(ctx, pageURL)
+		if guessed && (pastLastPage(err) || err == nil && len(bytes.TrimSpace(body)) == 0) {
+			break
+		}
+		if err != nil {
+			return pages, err
+		}
+		hash := sha256.Sum256(body)
+		if pages > 0 && hash == lastHash {
+			break
+		}
+		lastHash = hash
+
+		page, err := f.ParsePage(string(body), pageURL)
+		if err != nil {
+			return pages, err
+		}
+		if !addGUIDs(seenGUIDs, page.Entries) && pages > 0 && len(page.Entries) > 0 {
+			break
+		}
+		pages++
+
+		more, err := visit(page)
+		if err != nil {
+			return pages, err
+		}
+		if !more || len(page.Entries) == 0 {
+			break
+		}
+
+		pageURL, guessed = page.Next, false
+		if pageURL == "" && opts.Paged {
+			pageURL, err = PagedURL(startURL, pages+1)
+			if err != nil {
+				return pages, err
+			}
+			guessed = true
+		}
+	}
+
+	return pages, nil
+}
+
+// addGUIDs adds the GUIDs of entries to seen and reports whether any was new.
+func addGUIDs(seen map[string]bool, entries []*model.Entry) bool {
+	added := false
+	for _, entry := range entries {
+		if !seen[entry.GUID] {
+			seen[entry.GUID] = true
+			added = true
+		}
+	}
+	return added
+}
+
+// pastLastPage reports whether err is how WordPress answers a ?paged=N
+// request beyond the last page.
+func pastLastPage(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && (status.StatusCode == http.StatusNotFound || status.StatusCode == http.StatusGone)
+}
+
+// PagedURL returns the WordPress-style URL for page n of a feed (?paged=n).
+func PagedURL(feedURL string, n int) (string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid feed URL %s: %w", feedURL, err)
+	}
+
+	q := u.Query()
+	q.Set("paged", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// archiveLink scans raw feed XML for a link to the next older page.
+// An archived feed's rel="prev-archive" is preferred over a paged feed's rel="next".
+func archiveLink(content string) string {
+	var prevArchive, next string
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "link" {
+			continue
+		}
+
+		var rel, href string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "rel":
+				rel = attr.Value
+			case "href":
+				href = attr.Value
+			}
+		}
+
+		switch rel {
+		case "prev-archive":
+			if prevArchive == "" {
+				prevArchive = href
+			}
+		case "next":
+			if next == "" {
+				next = href
+			}
+		}
+	}
+
+	if prevArchive != "" {
+		return prevArchive
+	}
+	return next
+}
+
+// resolveLink resolves a possibly relative link against base.
+func resolveLink(base, link string) string {
+	b, err := url.Parse(base)
+	if err != nil || base == "" {
+		return link
+	}
+	l, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return b.ResolveReference(l).String()
+}
@@ -0,0 +1,232 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// archivePage builds an Atom page with one entry and an optional pagination link.
+func archivePage(id, rel, href string) string {
+	link := ""
+	if rel != "" {
+		link = fmt.Sprintf(`<link rel="%s" href="%s"/>`, rel, href)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Archived Feed</title>
+  %s
+  <entry>
+    <title>Entry %s</title>
+    <link href="https://example.com/%s"/>
+    <id>%s</id>
+    <updated>2024-12-26T10:00:00Z</updated>
+  </entry>
+</feed>`, link, id, id, id)
+}
+
+func TestParsePage_PrevArchive(t *testing.T) {
+	fetcher := NewFetcher()
+
+	page, err := fetcher.ParsePage(archivePage("a", "prev-archive", "/archive/2024"), "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "https://example.com/archive/2024", page.Next, "Relative links should resolve against the page URL")
+}
+
+func TestParsePage_PrefersPrevArchiveOverNext(t *testing.T) {
+	content := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Both</title>
+  <link rel="next" href="https://example.com/page2"/>
+  <link rel="prev-archive" href="https://example.com/archive"/>
+  <entry><id>x</id><title>X</title></entry>
+</feed>`
+
+	page, err := NewFetcher().ParsePage(content, "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/archive", page.Next)
+}
+
+func TestParsePage_NoLink(t *testing.T) {
+	data := archivePage("a", "", "")
+
+	page, err := NewFetcher().ParsePage(data, "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Empty(t, page.Next)
+}
+
+func TestPagedURL(t *testing.T) {
+	u, err := PagedURL("https://example.com/feed/?lang=en", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/feed/?lang=en&paged=3", u)
+}
+
+func TestWalkArchive_FollowsLinks(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			fmt.Fprint(w, archivePage("1", "prev-archive", srv.URL+"/archive/2"))
+		case "/archive/2":
+			fmt.Fprint(w, archivePage("2", "prev-archive", "/archive/3"))
+		case "/archive/3":
+			// Points back at itself; the walker must not loop
+			fmt.Fprint(w, archivePage("3", "prev-archive", "/archive/3"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var guids []string
+	pages, err := NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{}, func(p *Page) (bool, error) {
+		for _, e := range p.Entries {
+			guids = append(guids, e.GUID)
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"1", "2", "3"}, guids)
+}
+
+func TestWalkArchive_MaxPagesAndStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := r.URL.Query().Get("paged")
+		if n == "" {
+			n = "1"
+		}
+		fmt.Fprint(w, archivePage(n, "", ""))
+	}))
+	defer srv.Close()
+
+	visit := func(p *Page) (bool, error) { return true, nil }
+
+	// Without --paged there is no link to follow
+	pages, err := NewFetcher().WalkArchive(srv.URL, ArchiveOptions{MaxPages: 5}, visit)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	// WordPress-style pagination is bounded by MaxPages
+	pages, err = NewFetcher().WalkArchive(srv.URL, ArchiveOptions{MaxPages: 4, Paged: true}, visit)
+	require.NoError(t, err)
+	assert.Equal(t, 4, pages)
+
+	// The visitor can stop the walk early
+	pages, err = NewFetcher().WalkArchive(srv.URL, ArchiveOptions{Paged: true}, func(p *Page) (bool, error) {
+		return p.Entries[0].GUID != "2", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+}
+
+func TestWalkArchive_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	pages, err := NewFetcher().WalkArchive(srv.URL, ArchiveOptions{}, func(p *Page) (bool, error) { return true, nil })
+	assert.Error(t, err)
+	assert.Equal(t, 0, pages)
+}
+
+func TestWalkArchive_PagedEndsAtMissingPage(t *testing.T) {
+	// WordPress answers 404 past the last ?paged=N page
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("paged") {
+		case "":
+			fmt.Fprint(w, archivePage("1", "", ""))
+		case "2":
+			fmt.Fprint(w, archivePage("2", "", ""))
+		case "3":
+			http.NotFound(w, r)
+		default:
+			t.Errorf("Fetched %s after the last page", r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	var guids []string
+	pages, err := NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{Paged: true}, func(p *Page) (bool, error) {
+		for _, e := range p.Entries {
+			guids = append(guids, e.GUID)
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"1", "2"}, guids)
+
+	// An empty page ends the walk too
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("paged") == "" {
+			fmt.Fprint(w, archivePage("1", "", ""))
+		}
+	})
+	pages, err = NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{Paged: true}, func(p *Page) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	// An advertised page that is missing is still an error
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed" {
+			fmt.Fprint(w, archivePage("1", "next", "/page/2"))
+			return
+		}
+		http.NotFound(w, r)
+	})
+	pages, err = NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{Paged: true}, func(p *Page) (bool, error) { return true, nil })
+	assert.Error(t, err)
+	assert.Equal(t, 1, pages)
+}
+
+func TestWalkArchive_PagedEndsWhenPagingIsIgnored(t *testing.T) {
+	// The site ignores ?paged=N and serves the front page every time
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, archivePage("1", "", ""))
+	}))
+	defer srv.Close()
+
+	pages, err := NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{Paged: true}, func(p *Page) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+	assert.Equal(t, 2, requests)
+
+	// Pages that differ but repeat entries already seen end the walk too
+	requests = 0
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, archivePage("1", "", ""), "<!-- ", r.URL.Query().Get("paged"), " -->")
+	})
+	pages, err = NewFetcher().WalkArchive(srv.URL+"/feed", ArchiveOptions{Paged: true}, func(p *Page) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+	assert.Equal(t, 2, requests)
+}
+
+func TestWalkArchiveContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, archivePage(r.URL.Path, "next", r.URL.Path+"x"))
+	}))
+	defer srv.Close()
+
+	// Canceled while visiting the second page
+	pages, err := NewFetcher().WalkArchiveContext(ctx, srv.URL+"/p", ArchiveOptions{}, func(p *Page) (bool, error) {
+		if p.Entries[0].GUID == "/px" {
+			cancel()
+		}
+		return true, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, pages)
+}
@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// rss is the RSS 2.0 document written by GenerateRSS.
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// GenerateRSS writes entries as an RSS 2.0 feed.
+func GenerateRSS(w io.Writer, title string, entries []*model.Entry) error {
+	doc := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         title,
+			Description:   title,
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+			Items:         []rssItem{},
+		},
+	}
+
+	for _, e := range entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Content,
+			GUID:        rssGUID{Value: e.GUID},
+			PubDate:     e.Published.Format(time.RFC1123Z),
+			Categories:  e.Tags,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode RSS: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write final newline: %w", err)
+	}
+	return nil
+}
+
+// GenerateBookmarks writes entries in the Netscape bookmark file format
+// understood by browsers and bookmarking services.
+func GenerateBookmarks(w io.Writer, title string, entries []*model.Entry) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	b.WriteString(`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n")
+	fmt.Fprintf(&b, "<TITLE>%s</TITLE>\n<H1>%s</H1>\n<DL><p>\n", html.EscapeString(title), html.EscapeString(title))
+
+	for _, e := range entries {
+		added := e.Published
+		if e.StarredAt != nil {
+			added = *e.StarredAt
+		}
+
+		fmt.Fprintf(&b, `    <DT><A HREF="%s" ADD_DATE="%d"`, html.EscapeString(e.Link), added.Unix())
+		if len(e.Tags) > 0 {
+			fmt.Fprintf(&b, ` TAGS="%s"`, html.EscapeString(strings.Join(e.Tags, ",")))
+		}
+		fmt.Fprintf(&b, ">%s</A>\n", html.EscapeString(e.Title))
+	}
+
+	b.WriteString("</DL><p>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write bookmarks: %w", err)
+	}
+	return nil
+}
+
+// GenerateNotesMarkdown writes entries with their notes and highlights as a
+// Markdown document: a section per entry linking to it, then the note, then
+// each highlight as a blockquote followed by its comment.
+func GenerateNotesMarkdown(w io.Writer, title string, entries []*model.Entry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+
+	for _, e := range entries {
+		heading := markdownEscaper.Replace(e.Title)
+		if e.Link != "" {
+			heading = fmt.Sprintf("[%s](<%s>)", heading, e.Link)
+		}
+		fmt.Fprintf(&b, "\n## %s\n", heading)
+
+		if e.Note != nil {
+			fmt.Fprintf(&b, "\n%s\n", e.Note.Body)
+		}
+		for _, h := range e.Highlights {
+			b.WriteString("\n> " + strings.ReplaceAll(h.Text, "\n", "\n> ") + "\n")
+			if h.Comment != "" {
+				fmt.Fprintf(&b, "\n%s\n", h.Comment)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return nil
+}
+
+// markdownEscaper escapes characters that would break a link label.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
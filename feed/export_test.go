@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportEntries() []*model.Entry {
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	starred := published.Add(time.Hour)
+	return []*model.Entry{
+		{GUID: "1", Title: "Go & Rust", Link: "https://example.com/1?a=1&b=2", Content: "<p>Hello</p>", Published: published, StarredAt: &starred, Tags: []string{"go", "rust"}},
+		{GUID: "2", Title: "Second", Link: "https://example.com/2", Published: published},
+	}
+}
+
+func TestGenerateRSS_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, GenerateRSS(&buf, "Starred", exportEntries()))
+
+	f := NewFetcher()
+	parsed, entries, err := f.Parse(buf.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Starred", parsed.Title)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Go & Rust", entries[0].Title)
+	assert.Equal(t, "https://example.com/1?a=1&b=2", entries[0].Link)
+	assert.Equal(t, "1", entries[0].GUID)
+	assert.Equal(t, "<p>Hello</p>", entries[0].Content)
+}
+
+func TestGenerateBookmarks(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, GenerateBookmarks(&buf, "Starred", exportEntries()))
+
+	out := buf.String()
+	assert.Contains(t, out, "<!DOCTYPE NETSCAPE-Bookmark-file-1>")
+	assert.Contains(t, out, `<DT><A HREF="https://example.com/1?a=1&amp;b=2" ADD_DATE="1704168245" TAGS="go,rust">Go &amp; Rust</A>`)
+	assert.Contains(t, out, `<DT><A HREF="https://example.com/2" ADD_DATE="1704164645">Second</A>`)
+}
+
+func TestGenerateNotesMarkdown(t *testing.T) {
+	entries := exportEntries()
+	entries[0].Note = &model.Note{Body: "Worth a *second* read."}
+	entries[0].Highlights = []*model.Highlight{
+		{Text: "first line\nsecond line", Comment: "Key point"},
+		{Text: "no comment"},
+	}
+	entries[1].Title = "[Draft] Second"
+	entries[1].Link = ""
+	entries[1].Highlights = []*model.Highlight{{Text: "quoted"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, GenerateNotesMarkdown(&buf, "Notes", entries))
+	assert.Equal(t, `# Notes
+
+## [Go & Rust](<https://example.com/1?a=1&b=2>)
+
+Worth a *second* read.
+
+> first line
+> second line
+
+Key point
+
+> no comment
+
+## \[Draft\] Second
+
+> quoted
+`, buf.String())
+}
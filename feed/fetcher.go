@@ -2,7 +2,10 @@
 package feed
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
@@ -10,31 +13,91 @@ import (
 	"github.com/robertmeta/feed-cli/model"
 )
 
+// requestTimeout bounds a whole request, body included, so a stalled server
+// cannot hang a fetch that has no deadline of its own.
+const requestTimeout = 60 * time.Second
+
 // Fetcher handles fetching and parsing RSS/Atom feeds.
 type Fetcher struct {
 	parser *gofeed.Parser
+	client *http.Client
+
+	// Links, if set, normalizes the links of fetched entries.
+	Links *LinkNormalizer
 }
 
 // NewFetcher creates a new Fetcher.
 func NewFetcher() *Fetcher {
+	client := &http.Client{Timeout: requestTimeout}
+	parser := gofeed.NewParser()
+	parser.Client = client
 	return &Fetcher{
-		parser: gofeed.NewParser(),
+		parser: parser,
+		client: client,
 	}
 }
 
 // Fetch retrieves and parses a feed from a URL.
 func (f *Fetcher) Fetch(url string) (*model.Feed, []*model.Entry, error) {
-	parsedFeed, err := f.parser.ParseURL(url)
+	return f.FetchContext(context.Background(), url)
+}
+
+// FetchContext is like Fetch but aborts the request when ctx is done.
+func (f *Fetcher) FetchContext(ctx context.Context, url string) (*model.Feed, []*model.Entry, error) {
+	parsedFeed, err := f.parser.ParseURLWithContext(url, ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to fetch feed from %s: %w", url, err)
 	}
 
 	feed, entries := f.convert(parsedFeed, url)
+	f.normalizeLinks(parsedFeed, "", url, entries)
 	return feed, entries, nil
 }
 
+// StatusError reports a response with an unexpected HTTP status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to fetch %s: http status %d", e.URL, e.StatusCode)
+}
+
+// get performs a plain HTTP GET and returns the body and response headers.
+func (f *Fetcher) get(ctx context.Context, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	req.Header.Set("User-Agent", f.parser.UserAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, &StatusError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	return body, resp.Header, nil
+}
+
 // Parse parses feed content from a string.
 func (f *Fetcher) Parse(content string) (*model.Feed, []*model.Entry, error) {
+	return f.parse(content, "")
+}
+
+// parse is like Parse, resolving relative entry links against base when
+// f.Links is set.
+func (f *Fetcher) parse(content, base string) (*model.Feed, []*model.Entry, error) {
 	if content == "" {
 		return nil, nil, fmt.Errorf("feed content is empty")
 	}
@@ -45,6 +108,7 @@ func (f *Fetcher) Parse(content string) (*model.Feed, []*model.Entry, error) {
 	}
 
 	feed, entries := f.convert(parsedFeed, "")
+	f.normalizeLinks(parsedFeed, content, base, entries)
 	return feed, entries, nil
 }
 
@@ -80,6 +144,12 @@ func (f *Fetcher) convertItem(item *gofeed.Item) *model.Entry {
 		IsRead: false, // New entries default to unread
 	}
 
+	if item.Author != nil {
+		entry.Author = item.Author.Name
+	} else if len(item.Authors) > 0 && item.Authors[0] != nil {
+		entry.Author = item.Authors[0].Name
+	}
+
 	// Use link as GUID if GUID is missing
 	if entry.GUID == "" {
 		entry.GUID = item.Link
@@ -100,23 +170,88 @@ func (f *Fetcher) convertItem(item *gofeed.Item) *model.Entry {
 	} else {
 		// Fallback to current time if no date found
 		entry.Published = time.Now()
+		entry.Undated = true
 	}
 
 	return entry
 }
 
+// FetchResult is the outcome of a conditional fetch.
+type FetchResult struct {
+	Feed     *model.Feed // Title, URL and the response's ETag/Last-Modified
+	Entries  []*model.Entry
+	Modified bool // false when the server answered 304 Not Modified
+	Bytes    int  // Size of the response body
+}
+
+// FetchConditional retrieves a feed using an HTTP conditional GET
+// (If-None-Match / If-Modified-Since). When the server reports the feed is
+// unchanged, Modified is false and no entries are returned.
+func (f *Fetcher) FetchConditional(url string, etag string, lastModified string) (*FetchResult, error) {
+	return f.FetchConditionalContext(context.Background(), url, etag, lastModified)
+}
+
+// FetchConditionalContext is like FetchConditional but aborts the request when ctx is done.
+func (f *Fetcher) FetchConditionalContext(ctx context.Context, url string, etag string, lastModified string) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed from %s: %w", url, err)
+	}
+	req.Header.Set("User-Agent", f.parser.UserAgent)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			Feed: &model.Feed{URL: url, ETag: etag, LastModified: lastModified},
+		}, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch feed from %s: http status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed from %s: %w", url, err)
+	}
+
+	parsedFeed, err := f.parser.ParseString(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed from %s: %w", url, err)
+	}
+
+	feed, entries := f.convert(parsedFeed, url)
+	f.normalizeLinks(parsedFeed, string(body), url, entries)
+	feed.ETag = resp.Header.Get("ETag")
+	feed.LastModified = resp.Header.Get("Last-Modified")
+
+	return &FetchResult{
+		Feed:     feed,
+		Entries:  entries,
+		Modified: true,
+		Bytes:    len(body),
+	}, nil
+}
+
 // FetchWithCache retrieves a feed with HTTP caching support (ETag, Last-Modified).
 // Returns the feed, entries, whether it was modified (true = new content, false = not modified), and any error.
 func (f *Fetcher) FetchWithCache(url string, etag string, lastModified string) (*model.Feed, []*model.Entry, bool, error) {
-	// For now, just fetch normally
-	// TODO: Implement HTTP conditional GET with If-None-Match and If-Modified-Since headers
-	feed, entries, err := f.Fetch(url)
+	result, err := f.FetchConditional(url, etag, lastModified)
 	if err != nil {
 		return nil, nil, false, err
 	}
 
-	// Always return modified=true for now (no caching yet)
-	return feed, entries, true, nil
+	return result.Feed, result.Entries, result.Modified, nil
 }
 
 // ExtractCategories extracts categories/tags from feed entries.
@@ -1,8 +1,12 @@
 package feed
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -159,3 +163,96 @@ func TestFetcher_HandlesEmptyContent(t *testing.T) {
 	assert.Equal(t, "Entry with no content", entries[0].Title)
 	assert.Equal(t, "", entries[0].Content) // Empty content is OK
 }
+
+func TestFetcher_FetchConditional(t *testing.T) {
+	data, err := os.ReadFile("../testdata/rss2.xml")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	fetcher := NewFetcher()
+
+	// First fetch returns content and cache validators
+	result, err := fetcher.FetchConditional(srv.URL, "", "")
+	require.NoError(t, err)
+	assert.True(t, result.Modified)
+	assert.Len(t, result.Entries, 3)
+	assert.Equal(t, len(data), result.Bytes)
+	assert.Equal(t, `"v1"`, result.Feed.ETag)
+	assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", result.Feed.LastModified)
+
+	// Second fetch with the ETag is not modified
+	result, err = fetcher.FetchConditional(srv.URL, `"v1"`, "")
+	require.NoError(t, err)
+	assert.False(t, result.Modified)
+	assert.Empty(t, result.Entries)
+	assert.Equal(t, `"v1"`, result.Feed.ETag, "Validators should carry over on 304")
+
+	// FetchWithCache reports the same
+	_, _, modified, err := fetcher.FetchWithCache(srv.URL, `"v1"`, "")
+	require.NoError(t, err)
+	assert.False(t, modified)
+}
+
+func TestFetcher_FetchConditional_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := NewFetcher().FetchConditional(srv.URL, "", "")
+	assert.Error(t, err)
+}
+
+func TestFetcher_FetchConditionalContext_Canceled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewFetcher().FetchConditionalContext(ctx, srv.URL, "", "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFetcher_ParsesAuthor(t *testing.T) {
+	data, err := os.ReadFile("../testdata/atom.xml")
+	require.NoError(t, err)
+
+	_, entries, err := NewFetcher().Parse(string(data))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "Test Author", entries[0].Author)
+}
+
+func TestFetcher_FlagsUndatedEntries(t *testing.T) {
+	rss := `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>T</title>
+<item><guid>dated</guid><title>Dated</title><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>
+<item><guid>missing</guid><title>Missing</title></item>
+<item><guid>garbled</guid><title>Garbled</title><pubDate>sometime last week</pubDate></item>
+</channel></rss>`
+
+	_, entries, err := NewFetcher().Parse(rss)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.False(t, entries[0].Undated)
+	assert.True(t, entries[1].Undated, "No date")
+	assert.True(t, entries[2].Undated, "Unparseable date")
+	assert.False(t, entries[2].Published.IsZero(), "Still dated with the parse time")
+}
@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/url"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// DefaultStripParams are the query parameters removed from entry links by
+// default: click tracking that says nothing about the page itself.
+var DefaultStripParams = []string{"utm_*", "fbclid", "gclid", "mc_cid", "mc_eid", "ref"}
+
+// LinkNormalizer cleans entry links at ingest. Relative links are resolved
+// against the item's xml:base or the feed URL, links through known
+// redirectors are replaced by their target and tracking parameters are
+// removed. Entries whose link changes keep the feed's link in OriginalLink.
+type LinkNormalizer struct {
+	StripParams []string // Query parameters to remove; a trailing * matches a prefix
+	Unwrap      bool     // Replace FeedBurner, Google News and similar redirects with their target
+}
+
+// DefaultLinkNormalizer returns a LinkNormalizer that unwraps redirectors and
+// strips DefaultStripParams.
+func DefaultLinkNormalizer() *LinkNormalizer {
+	return &LinkNormalizer{StripParams: DefaultStripParams, Unwrap: true}
+}
+
+// redirector is a link wrapper that carries its target in a query parameter.
+type redirector struct {
+	host, path string
+	params     []string // Tried in order
+}
+
+var redirectors = []redirector{
+	{"www.google.com", "/url", []string{"q", "url"}},
+	{"google.com", "/url", []string{"q", "url"}},
+	{"news.google.com", "/news/url", []string{"url"}},
+	{"l.facebook.com", "/l.php", []string{"u"}},
+}
+
+// maxUnwrap bounds how many nested redirectors are unwrapped.
+const maxUnwrap = 5
+
+// Normalize returns link resolved against base (if relative), unwrapped and
+// stripped. Links that cannot be parsed are returned unchanged.
+func (n *LinkNormalizer) Normalize(link, base string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	if !u.IsAbs() && base != "" {
+		if b, err := url.Parse(base); err == nil {
+			u = b.ResolveReference(u)
+		}
+	}
+
+	if n.Unwrap {
+		for i := 0; i < maxUnwrap; i++ {
+			target := unwrapLink(u)
+			if target == nil {
+				break
+			}
+			u = target
+		}
+	}
+
+	u.RawQuery = n.stripQuery(u.RawQuery)
+	return u.String()
+}
+
+// CanonicalLink returns the form of an http(s) link that duplicate entries
+// share: normalized as DefaultLinkNormalizer would at ingest, then without
+// scheme, "www." or default port, with a lowercase host, no fragment or
+// trailing slash and the query sorted. Other links return "".
+func CanonicalLink(link string) string {
+	u, err := url.Parse(DefaultLinkNormalizer().Normalize(link, ""))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	canonical := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if query := u.Query(); len(query) > 0 {
+		canonical += "?" + query.Encode()
+	}
+	return canonical
+}
+
+// stripQuery removes StripParams from a raw query, leaving the other
+// parameters exactly as they were.
+func (n *LinkNormalizer) stripQuery(rawQuery string) string {
+	if rawQuery == "" || len(n.StripParams) == 0 {
+		return rawQuery
+	}
+
+	kept := []string{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		key := param
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key = key[:i]
+		}
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if param != "" && !n.strips(key) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// strips reports whether key matches one of StripParams, ignoring case.
+func (n *LinkNormalizer) strips(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range n.StripParams {
+		p = strings.ToLower(p)
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapLink returns the target of a redirector link, or nil if u is not one.
+func unwrapLink(u *url.URL) *url.URL {
+	host := strings.ToLower(u.Hostname())
+
+	var target string
+	if host == "news.google.com" {
+		for _, prefix := range []string{"/rss/articles/", "/articles/"} {
+			if id, ok := strings.CutPrefix(u.Path, prefix); ok {
+				target = googleNewsTarget(id)
+			}
+		}
+	}
+	for _, r := range redirectors {
+		if target != "" {
+			break
+		}
+		if host != r.host || u.Path != r.path {
+			continue
+		}
+		query := u.Query()
+		for _, p := range r.params {
+			if v := query.Get(p); v != "" {
+				target = v
+				break
+			}
+		}
+	}
+	if target == "" {
+		return nil
+	}
+
+	t, err := url.Parse(target)
+	if err != nil || (t.Scheme != "http" && t.Scheme != "https") || t.Host == "" {
+		return nil
+	}
+	return t
+}
+
+// googleNewsTarget decodes the article URL embedded in a Google News
+// article ID: base64 of a small protobuf message whose first string field is
+// the URL. Newer IDs hold only a reference that takes a request to resolve;
+// they return "".
+func googleNewsTarget(id string) string {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil {
+		return ""
+	}
+	data, ok := bytes.CutPrefix(data, []byte{0x08, 0x13, 0x22})
+	if !ok {
+		return ""
+	}
+	length, size := binary.Uvarint(data)
+	if size <= 0 || length > uint64(len(data)-size) {
+		return ""
+	}
+	return string(data[size : size+int(length)])
+}
+
+// normalizeLinks applies f.Links to entries converted from gf. content is
+// the raw document, used for xml:base; base is the feed URL.
+func (f *Fetcher) normalizeLinks(gf *gofeed.Feed, content, base string, entries []*model.Entry) {
+	if f.Links == nil {
+		return
+	}
+
+	raw := matchItems(scanXMLItems([]byte(content)), entries)
+	for i, e := range entries {
+		itemBase := base
+		if raw[i].Base != "" {
+			itemBase = resolveLink(base, raw[i].Base)
+		}
+
+		link := e.Link
+		if f.Links.Unwrap && i < len(gf.Items) {
+			if orig := feedBurnerOrigLink(gf.Items[i]); orig != "" {
+				link = orig
+			}
+		}
+
+		if normalized := f.Links.Normalize(link, itemBase); normalized != e.Link {
+			e.OriginalLink = e.Link
+			e.Link = normalized
+		}
+	}
+}
+
+// feedBurnerOrigLink returns the link FeedBurner replaced with its own
+// tracking redirect, kept in the feedburner:origLink element.
+func feedBurnerOrigLink(item *gofeed.Item) string {
+	for _, ext := range item.Extensions["feedburner"]["origLink"] {
+		if v := strings.TrimSpace(ext.Value); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package feed
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// googleNewsLink builds a news.google.com article link in the older format
+// that embeds its target.
+func googleNewsLink(target string) string {
+	data := append([]byte{0x08, 0x13, 0x22, byte(len(target))}, target...)
+	return "https://news.google.com/rss/articles/" + base64.RawURLEncoding.EncodeToString(data) + "?oc=5"
+}
+
+func TestLinkNormalizer_Normalize(t *testing.T) {
+	n := DefaultLinkNormalizer()
+	google := "https://www.google.com/url?sa=t&url=" + url.QueryEscape("https://example.com/a?utm_source=g&id=1")
+
+	tests := []struct {
+		link, base, want string
+	}{
+		{"https://example.com/a?b=2&utm_source=x&UTM_Medium=y&a=1#top", "", "https://example.com/a?b=2&a=1#top"},
+		{"https://example.com/a?fbclid=abc&ref=hn", "", "https://example.com/a"},
+		{"https://example.com/a?reference=1", "", "https://example.com/a?reference=1"},
+		{"/posts/1", "https://example.com/blog/feed.xml", "https://example.com/posts/1"},
+		{"posts/1", "https://example.com/blog/", "https://example.com/blog/posts/1"},
+		{google, "", "https://example.com/a?id=1"},
+		{"https://l.facebook.com/l.php?u=" + url.QueryEscape(google), "", "https://example.com/a?id=1"},
+		{googleNewsLink("https://example.com/story"), "", "https://example.com/story"},
+		{"https://news.google.com/rss/articles/AU_yqLopaque", "", "https://news.google.com/rss/articles/AU_yqLopaque"},
+		{"https://www.google.com/url?q=javascript:alert(1)", "", "https://www.google.com/url?q=javascript:alert(1)"},
+		{"mailto:someone@example.com", "", "mailto:someone@example.com"},
+		{"", "https://example.com/", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, n.Normalize(tt.link, tt.base), tt.link)
+	}
+}
+
+func TestLinkNormalizer_Configurable(t *testing.T) {
+	n := &LinkNormalizer{StripParams: []string{"source", "mc_*"}}
+	google := "https://www.google.com/url?q=https://example.com/"
+
+	assert.Equal(t, "https://example.com/a?utm_source=x", n.Normalize("https://example.com/a?utm_source=x&source=rss&mc_cid=1", ""))
+	assert.Equal(t, google, n.Normalize(google, ""), "Redirects are kept without Unwrap")
+}
+
+func TestCanonicalLink(t *testing.T) {
+	tests := map[string]string{
+		"https://www.Example.com/a/?utm_source=x&b=2&a=1#top": "example.com/a?a=1&b=2",
+		"http://example.com:80/a":                             "example.com/a",
+		"https://example.com:8443/a?fbclid=1&ref=hn":          "example.com:8443/a",
+		"https://example.com/a?mc_cid=1&mc_eid=2&id=3":        "example.com/a?id=3",
+		"mailto:someone@example.com":                          "",
+		"/relative/path":                                      "",
+		"":                                                    "",
+	}
+	for link, want := range tests {
+		assert.Equal(t, want, CanonicalLink(link), link)
+	}
+}
+
+func TestFetcher_NormalizesLinks(t *testing.T) {
+	content := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:feedburner="http://rssnamespace.org/feedburner/ext/1.0">
+  <channel xml:base="https://example.com/blog/">
+    <title>Links</title>
+    <item><guid>relative</guid><title>Relative</title><link>posts/1?utm_source=rss</link></item>
+    <item xml:base="https://other.example.com/"><guid>base</guid><title>Item base</title><link>posts/2</link></item>
+    <item>
+      <guid>proxied</guid><title>FeedBurner</title>
+      <link>http://feedproxy.google.com/~r/Example/~3/abc/</link>
+      <feedburner:origLink>https://example.com/posts/3</feedburner:origLink>
+    </item>
+    <item><guid>clean</guid><title>Clean</title><link>https://example.com/posts/4</link></item>
+  </channel>
+</rss>`
+
+	f := NewFetcher()
+	f.Links = DefaultLinkNormalizer()
+	page, err := f.ParsePage(content, "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 4)
+
+	assert.Equal(t, "https://example.com/blog/posts/1", page.Entries[0].Link)
+	assert.Equal(t, "posts/1?utm_source=rss", page.Entries[0].OriginalLink)
+	assert.Equal(t, "https://other.example.com/posts/2", page.Entries[1].Link)
+	assert.Equal(t, "https://example.com/posts/3", page.Entries[2].Link)
+	assert.Equal(t, "http://feedproxy.google.com/~r/Example/~3/abc/", page.Entries[2].OriginalLink)
+	assert.Equal(t, "https://example.com/posts/4", page.Entries[3].Link)
+	assert.Empty(t, page.Entries[3].OriginalLink, "Unchanged links keep no original")
+	assert.Equal(t, "relative", page.Entries[0].GUID)
+
+	// Without a normalizer links are left alone
+	_, entries, err := NewFetcher().Parse(content)
+	require.NoError(t, err)
+	assert.Equal(t, "posts/1?utm_source=rss", entries[0].Link)
+	assert.Empty(t, entries[0].OriginalLink)
+}
+
+func TestFetcher_NormalizesLinks_SkippedItem(t *testing.T) {
+	// The parser skips the extension's entry element, so items no longer
+	// line up with entries by position
+	content := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:ext="https://example.com/ns/ext">
+  <channel xml:base="https://example.com/blog/">
+    <title>Skipped</title>
+    <ext:entry xml:base="https://wrong.example.com/"><ext:id>not-an-item</ext:id></ext:entry>
+    <item><guid>first</guid><title>First</title><link>posts/1</link></item>
+    <item xml:base="https://other.example.com/"><title>No GUID</title><link>posts/2</link></item>
+  </channel>
+</rss>`
+
+	f := NewFetcher()
+	f.Links = DefaultLinkNormalizer()
+	page, err := f.ParsePage(content, "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+
+	assert.Equal(t, "https://example.com/blog/posts/1", page.Entries[0].Link, "Matched by GUID")
+	assert.Equal(t, "https://other.example.com/posts/2", page.Entries[1].Link, "Matched by link")
+}
@@ -0,0 +1,401 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// Severity levels for validation issues.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// MaxItemSize is the content size above which an item is reported as oversized.
+const MaxItemSize = 256 * 1024
+
+// futureDateTolerance allows for clock skew before a date counts as "in the future".
+const futureDateTolerance = time.Hour
+
+// Issue is a single problem found while validating a feed.
+type Issue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Item     int    `json:"item,omitempty"` // 1-based item index (0 = feed-level issue)
+	GUID     string `json:"guid,omitempty"`
+}
+
+// Report is the result of validating a feed.
+type Report struct {
+	Source      string  `json:"source"`
+	Format      string  `json:"format,omitempty"`
+	ContentType string  `json:"content_type,omitempty"`
+	Valid       bool    `json:"valid"`
+	Items       int     `json:"items"`
+	Errors      int     `json:"errors"`
+	Warnings    int     `json:"warnings"`
+	Issues      []Issue `json:"issues"`
+}
+
+func (r *Report) add(severity, code string, item int, guid, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Item:     item,
+		GUID:     guid,
+	})
+
+	switch severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+}
+
+// rawItem holds the details of an item that the model conversion hides
+// (fallback GUIDs, fallback dates, xml:base scope).
+type rawItem struct {
+	HasGUID  bool
+	GUID     string // As written, to match the item to its entry
+	Link     string // As written, before any normalization
+	DateText string
+	Base     string
+}
+
+// matchItems returns the raw item each entry was converted from. Items are
+// matched by position when they agree on GUID or link, otherwise by GUID and
+// then link, since the scan may see items the parser skipped. Entries
+// without a match get a zero rawItem.
+func matchItems(raw []rawItem, entries []*model.Entry) []rawItem {
+	byGUID := map[string]int{}
+	byLink := map[string]int{}
+	for i := len(raw) - 1; i >= 0; i-- {
+		if raw[i].GUID != "" {
+			byGUID[raw[i].GUID] = i
+		}
+		if raw[i].Link != "" {
+			byLink[raw[i].Link] = i
+		}
+	}
+
+	matched := make([]rawItem, len(entries))
+	for i, e := range entries {
+		link := e.Link
+		if e.OriginalLink != "" {
+			link = e.OriginalLink
+		}
+		same := func(r rawItem) bool {
+			return r.GUID != "" && r.GUID == e.GUID || r.Link != "" && r.Link == link
+		}
+
+		if i < len(raw) && same(raw[i]) {
+			matched[i] = raw[i]
+		} else if j, ok := byGUID[e.GUID]; ok {
+			matched[i] = raw[j]
+		} else if j, ok := byLink[link]; ok {
+			matched[i] = raw[j]
+		} else if len(raw) == len(entries) {
+			matched[i] = raw[i]
+		}
+	}
+	return matched
+}
+
+// ValidateURL fetches a feed and validates it, including its Content-Type.
+func (f *Fetcher) ValidateURL(feedURL string) (*Report, error) {
+	return f.ValidateURLContext(context.Background(), feedURL)
+}
+
+// ValidateURLContext is like ValidateURL but aborts the request when ctx is done.
+func (f *Fetcher) ValidateURLContext(ctx context.Context, feedURL string) (*Report, error) {
+	body, header, err := f.get(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Validate(feedURL, body, header.Get("Content-Type")), nil
+}
+
+// Validate lints feed content against common RSS/Atom/JSON Feed rules.
+// contentType may be empty when the content did not come from HTTP.
+func (f *Fetcher) Validate(source string, content []byte, contentType string) *Report {
+	report := &Report{
+		Source:      source,
+		ContentType: contentType,
+		Issues:      []Issue{},
+	}
+	defer func() { report.Valid = report.Errors == 0 }()
+
+	checkEncoding(report, content)
+
+	switch gofeed.DetectFeedType(strings.NewReader(string(content))) {
+	case gofeed.FeedTypeRSS:
+		report.Format = "rss"
+	case gofeed.FeedTypeAtom:
+		report.Format = "atom"
+	case gofeed.FeedTypeJSON:
+		report.Format = "json"
+	}
+
+	checkContentType(report)
+
+	feed, entries, err := f.Parse(string(content))
+	end := time.Now()
+	if err != nil {
+		report.add(SeverityError, "parse_error", 0, "", "%v", err)
+		return report
+	}
+	report.Items = len(entries)
+
+	if strings.TrimSpace(feed.Title) == "" {
+		report.add(SeverityError, "missing_title", 0, "", "feed has no title")
+	}
+
+	var raw []rawItem
+	if report.Format == "json" {
+		raw = scanJSONItems(content)
+	} else {
+		raw = scanXMLItems(content)
+	}
+	raw = matchItems(raw, entries)
+
+	guids := make(map[string]int)
+	for i, entry := range entries {
+		n := i + 1
+		item := raw[i]
+
+		// GUIDs
+		switch {
+		case entry.GUID == "":
+			report.add(SeverityError, "missing_guid", n, "", "item %q has neither a GUID nor a link", entry.Title)
+		case !item.HasGUID:
+			report.add(SeverityWarning, "missing_guid", n, entry.GUID, "item has no GUID; falling back to its link")
+		}
+		if entry.GUID != "" {
+			if first, ok := guids[entry.GUID]; ok {
+				report.add(SeverityError, "duplicate_guid", n, entry.GUID, "GUID duplicates item %d", first)
+			} else {
+				guids[entry.GUID] = n
+			}
+		}
+
+		// Dates: the parser flags entries it dated with the current time
+		switch {
+		case item.DateText == "" && entry.Undated:
+			report.add(SeverityWarning, "missing_date", n, entry.GUID, "item has no date")
+		case entry.Undated:
+			report.add(SeverityError, "unparseable_date", n, entry.GUID, "cannot parse date %q", item.DateText)
+		case entry.Published.After(end.Add(futureDateTolerance)):
+			report.add(SeverityWarning, "future_date", n, entry.GUID, "item is dated in the future (%s)", entry.Published.Format(time.RFC3339))
+		}
+
+		// Links
+		if entry.Link != "" {
+			if u, err := url.Parse(entry.Link); err != nil {
+				report.add(SeverityError, "invalid_link", n, entry.GUID, "cannot parse link %q", entry.Link)
+			} else if !u.IsAbs() && item.Base == "" {
+				report.add(SeverityError, "relative_link", n, entry.GUID, "link %q is relative and no xml:base is in scope", entry.Link)
+			}
+		}
+
+		// Size
+		if size := len(entry.Content); size > MaxItemSize {
+			report.add(SeverityWarning, "oversized_item", n, entry.GUID, "item content is %d bytes (limit %d)", size, MaxItemSize)
+		}
+	}
+
+	return report
+}
+
+// xmlEncodingPattern extracts the encoding from an XML declaration.
+var xmlEncodingPattern = regexp.MustCompile(`^\s*<\?xml[^>]*encoding=["']([^"']+)["']`)
+
+// checkEncoding verifies that content declared (or defaulting) to UTF-8 is valid UTF-8.
+func checkEncoding(report *Report, content []byte) {
+	encoding := "utf-8"
+	if m := xmlEncodingPattern.FindSubmatch(content); m != nil {
+		encoding = strings.ToLower(string(m[1]))
+	}
+
+	switch encoding {
+	case "utf-8", "utf8", "us-ascii":
+		if !utf8.Valid(content) {
+			report.add(SeverityError, "invalid_encoding", 0, "", "content is not valid %s", encoding)
+		}
+	default:
+		report.add(SeverityInfo, "non_utf8_encoding", 0, "", "feed declares encoding %q", encoding)
+	}
+}
+
+// contentTypes lists the acceptable media types for each feed format.
+var contentTypes = map[string][]string{
+	"rss":  {"application/rss+xml", "application/rdf+xml", "application/xml", "text/xml"},
+	"atom": {"application/atom+xml", "application/xml", "text/xml"},
+	"json": {"application/feed+json", "application/json"},
+}
+
+// checkContentType compares the HTTP Content-Type with the detected format.
+func checkContentType(report *Report) {
+	if report.ContentType == "" {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(report.ContentType)
+	if err != nil {
+		report.add(SeverityWarning, "content_type_mismatch", 0, "", "cannot parse Content-Type %q", report.ContentType)
+		return
+	}
+
+	allowed, ok := contentTypes[report.Format]
+	if !ok {
+		return
+	}
+	for _, t := range allowed {
+		if mediaType == t {
+			return
+		}
+	}
+
+	report.add(SeverityWarning, "content_type_mismatch", 0, "", "%s feed served as %s (expected one of %s)",
+		report.Format, mediaType, strings.Join(allowed, ", "))
+}
+
+// scanXMLItems walks RSS/Atom XML and records per-item GUID, link, date and
+// xml:base details. Bases are resolved against each other but not against
+// the feed URL.
+func scanXMLItems(content []byte) []rawItem {
+	var items []rawItem
+	var bases []string
+	var current *rawItem
+	itemDepth := 0
+	depth := 0
+
+	decoder := xml.NewDecoder(strings.NewReader(string(content)))
+	decoder.Strict = false
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			base := ""
+			if len(bases) > 0 {
+				base = bases[len(bases)-1]
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "base" && (attr.Name.Space == "xml" || attr.Name.Space == "http://www.w3.org/XML/1998/namespace") {
+					base = resolveLink(base, strings.TrimSpace(attr.Value))
+				}
+			}
+			bases = append(bases, base)
+
+			name := t.Name.Local
+			if current == nil && (name == "item" || name == "entry") {
+				items = append(items, rawItem{Base: base})
+				current = &items[len(items)-1]
+				itemDepth = depth
+				continue
+			}
+			if current == nil || depth != itemDepth+1 {
+				continue
+			}
+
+			// text consumes the element, end tag included
+			text := func() string {
+				var text string
+				decoder.DecodeElement(&text, &t)
+				depth--
+				bases = bases[:len(bases)-1]
+				return strings.TrimSpace(text)
+			}
+
+			switch name {
+			case "guid", "id":
+				current.HasGUID = true
+				current.GUID = text()
+			case "link":
+				// Atom links are href attributes, RSS links text
+				var rel, href string
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "rel":
+						rel = attr.Value
+					case "href":
+						href = strings.TrimSpace(attr.Value)
+					}
+				}
+				if href == "" {
+					href = text()
+				}
+				if current.Link == "" && (rel == "" || rel == "alternate") {
+					current.Link = href
+				}
+			case "pubDate", "published", "updated", "date", "issued", "modified":
+				if date := text(); current.DateText == "" {
+					current.DateText = date
+				}
+			}
+
+		case xml.EndElement:
+			if current != nil && depth == itemDepth {
+				current = nil
+			}
+			depth--
+			if len(bases) > 0 {
+				bases = bases[:len(bases)-1]
+			}
+		}
+	}
+
+	return items
+}
+
+// scanJSONItems records per-item GUID and date details of a JSON Feed.
+func scanJSONItems(content []byte) []rawItem {
+	var doc struct {
+		Items []struct {
+			ID            json.RawMessage `json:"id"`
+			DatePublished string          `json:"date_published"`
+			DateModified  string          `json:"date_modified"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil
+	}
+
+	items := make([]rawItem, len(doc.Items))
+	for i, it := range doc.Items {
+		items[i] = rawItem{
+			HasGUID:  len(it.ID) > 0 && string(it.ID) != "null" && string(it.ID) != `""`,
+			DateText: it.DatePublished,
+		}
+		if items[i].DateText == "" {
+			items[i].DateText = it.DateModified
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueCodes returns the codes of all issues with the given severity.
+func issueCodes(r *Report, severity string) []string {
+	var codes []string
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			codes = append(codes, issue.Code)
+		}
+	}
+	return codes
+}
+
+func TestValidate_CleanFixtures(t *testing.T) {
+	fetcher := NewFetcher()
+
+	for _, name := range []string{"rss2.xml", "atom.xml"} {
+		data, err := os.ReadFile("../testdata/" + name)
+		require.NoError(t, err)
+
+		report := fetcher.Validate(name, data, "")
+		assert.True(t, report.Valid, "%s should be valid: %+v", name, report.Issues)
+		assert.Empty(t, issueCodes(report, SeverityError), name)
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title></title>
+    <item>
+      <title>No GUID</title>
+      <link>https://example.com/a</link>
+      <pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+    </item>
+    <item>
+      <title>Dup 1</title>
+      <guid>dup</guid>
+      <link>/relative</link>
+      <pubDate>not a date</pubDate>
+    </item>
+    <item>
+      <title>Dup 2</title>
+      <guid>dup</guid>
+      <pubDate>Fri, 01 Jan 2100 00:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Undated</title>
+      <guid>undated</guid>
+    </item>
+  </channel>
+</rss>`
+
+	report := NewFetcher().Validate("test", []byte(content), "text/html; charset=utf-8")
+	assert.False(t, report.Valid)
+	assert.Equal(t, "rss", report.Format)
+	assert.Equal(t, 4, report.Items)
+
+	errors := issueCodes(report, SeverityError)
+	assert.Contains(t, errors, "missing_title")
+	assert.Contains(t, errors, "duplicate_guid")
+	assert.Contains(t, errors, "relative_link")
+	assert.Contains(t, errors, "unparseable_date")
+
+	warnings := issueCodes(report, SeverityWarning)
+	assert.Contains(t, warnings, "missing_guid")
+	assert.Contains(t, warnings, "future_date")
+	assert.Contains(t, warnings, "missing_date")
+	assert.Contains(t, warnings, "content_type_mismatch")
+}
+
+func TestValidate_XMLBaseAllowsRelativeLinks(t *testing.T) {
+	content := `<?xml version="1.0"?>
+<rss version="2.0" xml:base="https://example.com/">
+  <channel>
+    <title>Base</title>
+    <item>
+      <guid>a</guid>
+      <link>posts/a</link>
+      <pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`
+
+	report := NewFetcher().Validate("test", []byte(content), "application/rss+xml")
+	assert.NotContains(t, issueCodes(report, SeverityError), "relative_link")
+	assert.Empty(t, issueCodes(report, SeverityWarning))
+}
+
+func TestValidate_InvalidEncoding(t *testing.T) {
+	content := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss version=\"2.0\"><channel><title>Bad \xff bytes</title></channel></rss>"
+
+	report := NewFetcher().Validate("test", []byte(content), "")
+	assert.False(t, report.Valid)
+	assert.Contains(t, issueCodes(report, SeverityError), "invalid_encoding")
+}
+
+func TestValidate_OversizedItem(t *testing.T) {
+	big := strings.Repeat("x", MaxItemSize+1)
+	content := `<?xml version="1.0"?><rss version="2.0"><channel><title>Big</title>` +
+		`<item><guid>a</guid><description>` + big + `</description><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>` +
+		`</channel></rss>`
+
+	report := NewFetcher().Validate("test", []byte(content), "")
+	assert.True(t, report.Valid)
+	assert.Equal(t, []string{"oversized_item"}, issueCodes(report, SeverityWarning))
+}
+
+func TestValidate_JSONFeed(t *testing.T) {
+	content := `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "JSON Feed",
+  "items": [
+    {"id": "1", "url": "https://example.com/1", "date_published": "2024-01-01T00:00:00Z"},
+    {"url": "https://example.com/2", "date_published": "2024-01-02T00:00:00Z"}
+  ]
+}`
+
+	report := NewFetcher().Validate("test", []byte(content), "application/feed+json")
+	assert.Equal(t, "json", report.Format)
+	assert.True(t, report.Valid, "%+v", report.Issues)
+	assert.Equal(t, []string{"missing_guid"}, issueCodes(report, SeverityWarning))
+}
+
+func TestValidate_ParseError(t *testing.T) {
+	report := NewFetcher().Validate("test", []byte("<html><body>nope</body></html>"), "text/html")
+	assert.False(t, report.Valid)
+	assert.Contains(t, issueCodes(report, SeverityError), "parse_error")
+}
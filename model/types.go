@@ -15,6 +15,8 @@ type Feed struct {
 	LastUpdated  *time.Time `json:"last_updated,omitempty"`
 	ETag         string     `json:"etag,omitempty"`
 	LastModified string     `json:"last_modified,omitempty"`
+	RetainCount  int        `json:"retain_count,omitempty"` // Keep only the newest N entries (0: global default)
+	RetainDays   int        `json:"retain_days,omitempty"`  // Drop entries older than N days (0: global default)
 }
 
 // Validate checks if the feed has required fields.
@@ -27,15 +29,41 @@ func (f *Feed) Validate() error {
 
 // Entry represents a single RSS/Atom entry/article.
 type Entry struct {
+	ID           int64      `json:"id"`
+	FeedID       int64      `json:"feed_id"`
+	GUID         string     `json:"guid"`
+	Title        string     `json:"title"`
+	Link         string     `json:"link"`
+	OriginalLink string     `json:"original_link,omitempty"` // The feed's link, when ingest changed it
+	Content      string     `json:"content,omitempty"`
+	Author       string     `json:"author,omitempty"`
+	Published    time.Time  `json:"published"`
+	Undated      bool       `json:"-"` // No parseable date; Published is the parse time
+	IsRead       bool       `json:"is_read"`
+	ReadAt       *time.Time `json:"read_at,omitempty"`
+	StarredAt    *time.Time `json:"starred_at,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	GroupID      int64      `json:"group_id,omitempty"` // Duplicate group (the ID of its first entry); 0 without duplicates
+
+	// Annotations are loaded for single entries and note listings only
+	Note       *Note        `json:"note,omitempty"`
+	Highlights []*Highlight `json:"highlights,omitempty"`
+}
+
+// Note is a reader's Markdown note on an entry.
+type Note struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Highlight is a quoted span of an entry, with an optional comment.
+type Highlight struct {
 	ID        int64     `json:"id"`
-	FeedID    int64     `json:"feed_id"`
-	GUID      string    `json:"guid"`
-	Title     string    `json:"title"`
-	Link      string    `json:"link"`
-	Content   string    `json:"content"`
-	Published time.Time `json:"published"`
-	IsRead    bool      `json:"is_read"`
-	Tags      []string  `json:"tags,omitempty"`
+	EntryID   int64     `json:"entry_id"`
+	Text      string    `json:"text"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // IsUnread returns true if the entry hasn't been read.
@@ -43,6 +71,11 @@ func (e *Entry) IsUnread() bool {
 	return !e.IsRead
 }
 
+// IsStarred returns true if the entry has been starred.
+func (e *Entry) IsStarred() bool {
+	return e.StarredAt != nil
+}
+
 // Age returns how long ago the entry was published.
 func (e *Entry) Age() time.Duration {
 	return time.Since(e.Published)
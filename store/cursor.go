@@ -0,0 +1,178 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or were issued
+// for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last entry of a page: its sort key and ID.
+// It is handed out base64-encoded and treated as opaque by callers.
+type cursor struct {
+	Sort  SortField `json:"s"`
+	Order SortOrder `json:"o"`
+	Key   string    `json:"k,omitempty"`
+	ID    int64     `json:"i"`
+}
+
+// EntryPage is one page of entries from ListEntries.
+type EntryPage struct {
+	Entries    []*model.Entry
+	Total      int    // Entries matching the filters, across all pages
+	NextCursor string // Empty on the last page
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// cursorKey returns the value of the sort column for e, as stored in a cursor.
+func cursorKey(field SortField, e *model.Entry) string {
+	switch field {
+	case SortPublished:
+		return strconv.FormatInt(e.Published.Unix(), 10)
+	case SortFeed:
+		return strconv.FormatInt(e.FeedID, 10)
+	case SortTitle:
+		return e.Title
+	case SortRead:
+		if e.ReadAt == nil {
+			return "0"
+		}
+		return strconv.FormatInt(e.ReadAt.Unix(), 10)
+	default:
+		return ""
+	}
+}
+
+// cursorAfter returns the cursor that resumes after e.
+func (o QueryOptions) cursorAfter(e *model.Entry) string {
+	field, order, err := o.sortSpec()
+	if err != nil {
+		return ""
+	}
+	return encodeCursor(cursor{Sort: field, Order: order, Key: cursorKey(field, e), ID: e.ID})
+}
+
+// cursorFilter returns the keyset condition that skips entries up to and
+// including the cursor position. The cursor must match Sort and Order.
+func (o QueryOptions) cursorFilter() (string, []interface{}, error) {
+	if o.Cursor == "" {
+		return "", nil, nil
+	}
+
+	c, err := decodeCursor(o.Cursor)
+	if err != nil {
+		return "", nil, err
+	}
+
+	field, order, err := o.sortSpec()
+	if err != nil {
+		return "", nil, err
+	}
+	if c.Sort != field || c.Order != order {
+		return "", nil, fmt.Errorf("%w: issued for --sort %s --order %s", ErrInvalidCursor, c.Sort, c.Order)
+	}
+
+	op := "<"
+	if order == SortAsc {
+		op = ">"
+	}
+
+	if field == SortID {
+		return " AND entries.id " + op + " ?", []interface{}{c.ID}, nil
+	}
+
+	var key interface{} = c.Key
+	keyExpr := "?"
+	if field == SortTitle {
+		// Fold the key the same way as the sort column
+		keyExpr = "COALESCE(LOWER(?), '')"
+	} else {
+		n, err := strconv.ParseInt(c.Key, 10, 64)
+		if err != nil {
+			return "", nil, ErrInvalidCursor
+		}
+		key = n
+	}
+
+	// Row values compare lexicographically, matching the ORDER BY tie-break
+	return " AND (" + sortColumns[field] + ", entries.id) " + op + " (" + keyExpr + ", ?)", []interface{}{key, c.ID}, nil
+}
+
+// CountEntries returns the number of entries matching the filters in opts.
+// Pagination (Limit, Offset, Cursor) is ignored.
+func (s *Store) CountEntries(opts QueryOptions) (int, error) {
+	where, args, err := opts.filter(s.userID)
+	if err != nil {
+		return 0, err
+	}
+	return s.countEntries(where, args)
+}
+
+// countEntries counts the entries matching the conditions in where.
+func (s *Store) countEntries(where string, args []interface{}) (int, error) {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM entries"+s.stateJoin()+" WHERE 1=1"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count entries: %w", err)
+	}
+	return count, nil
+}
+
+// ListEntries returns a page of entries like GetEntries, together with the
+// total number of matching entries and a cursor for the next page. Paging with
+// cursors is stable while new entries arrive, unlike Offset.
+func (s *Store) ListEntries(opts QueryOptions) (*EntryPage, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The total counts what the pages list, which Sort may narrow
+	where, args, err := opts.listFilter(s.userID)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.countEntries(where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra entry to learn whether there is a next page
+	limit := opts.Limit
+	if limit > 0 {
+		opts.Limit = limit + 1
+	}
+
+	entries, err := s.GetEntries(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EntryPage{Entries: entries, Total: total}
+	if limit > 0 && len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = opts.cursorAfter(entries[limit-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectPages follows next cursors from opts and returns every GUID seen.
+func collectPages(t *testing.T, s *Store, opts QueryOptions, between func()) []string {
+	guids := []string{}
+	for i := 0; i < 20; i++ {
+		page, err := s.ListEntries(opts)
+		require.NoError(t, err)
+		guids = append(guids, entryGUIDs(page.Entries)...)
+		if page.NextCursor == "" {
+			return guids
+		}
+		if between != nil {
+			between()
+		}
+		opts.Cursor = page.NextCursor
+	}
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func TestListEntries_CursorPagination(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	page, err := s.ListEntries(QueryOptions{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, page.Total)
+	assert.Equal(t, []string{"b2", "b1", "a2"}, entryGUIDs(page.Entries))
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = s.ListEntries(QueryOptions{Limit: 3, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, 4, page.Total, "Total ignores the cursor")
+	assert.Equal(t, []string{"a1"}, entryGUIDs(page.Entries))
+	assert.Empty(t, page.NextCursor)
+
+	// An exactly full last page has no next cursor
+	page, err = s.ListEntries(QueryOptions{Limit: 4})
+	require.NoError(t, err)
+	assert.Empty(t, page.NextCursor)
+
+	// Other sort orders, including ties broken by ID
+	for _, opts := range []QueryOptions{
+		{Limit: 1, Order: SortAsc},
+		{Limit: 3, Sort: SortTitle, Order: SortAsc},
+		{Limit: 1, Sort: SortFeed},
+		{Limit: 2, Sort: SortID, Order: SortAsc},
+	} {
+		want, err := s.GetEntries(QueryOptions{Sort: opts.Sort, Order: opts.Order})
+		require.NoError(t, err)
+		assert.Equal(t, entryGUIDs(want), collectPages(t, s, opts, nil), "sort %s %s", opts.Sort, opts.Order)
+	}
+}
+
+func TestListEntries_CursorPaginationUntitled(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	// Untitled entries: an empty title and, as older databases may have,
+	// a NULL one
+	for _, e := range []*model.Entry{
+		{FeedID: 1, GUID: "empty", Published: time.Now()},
+		{FeedID: 1, GUID: "null", Published: time.Now()},
+	} {
+		require.NoError(t, s.SaveEntry(e))
+	}
+	_, err := s.db.Exec("UPDATE entries SET title = NULL WHERE guid = 'null'")
+	require.NoError(t, err)
+
+	for _, order := range []SortOrder{SortAsc, SortDesc} {
+		want, err := s.GetEntries(QueryOptions{Sort: SortTitle, Order: order})
+		require.NoError(t, err)
+		require.Len(t, want, 6)
+		assert.Equal(t, entryGUIDs(want), collectPages(t, s, QueryOptions{Limit: 1, Sort: SortTitle, Order: order}, nil), order)
+	}
+
+	got, err := s.GetEntries(QueryOptions{Sort: SortTitle, Order: SortAsc})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"empty", "null"}, entryGUIDs(got[:2]), "Untitled entries sort first")
+}
+
+func TestListEntries_StableWhileEntriesArrive(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	n := 0
+	insert := func() {
+		n++
+		e := &model.Entry{FeedID: 1, GUID: fmt.Sprintf("new%d", n), Title: "new", Published: time.Now()}
+		require.NoError(t, s.SaveEntry(e))
+	}
+
+	// New entries sort first and must not shift later pages
+	assert.Equal(t, []string{"b2", "b1", "a2", "a1"}, collectPages(t, s, QueryOptions{Limit: 1}, insert))
+}
+
+func TestListEntries_InvalidCursor(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	page, err := s.ListEntries(QueryOptions{Limit: 1})
+	require.NoError(t, err)
+
+	_, err = s.ListEntries(QueryOptions{Limit: 1, Cursor: page.NextCursor, Order: SortAsc})
+	assert.ErrorIs(t, err, ErrInvalidCursor, "Cursor from a different sort order")
+
+	_, err = s.ListEntries(QueryOptions{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = s.ListEntries(QueryOptions{Cursor: page.NextCursor, Offset: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialect holds what differs between the SQL engines a Store can run on.
+// Queries are written for SQLite, with "?" or "?N" placeholders, and in SQL
+// both engines accept; rebind adapts the placeholders.
+type dialect interface {
+	name() string
+	driverName() string
+	// dataSource returns the driver's data source name for a --db value.
+	dataSource(path string) string
+	rebind(query string) string
+	migrations() []Migration
+	schemaVersion(c *conn) (int, error)
+	setSchemaVersion(version int) string
+	// searchQuery selects entryColumns, a highlighted snippet and a rank
+	// (lower is better) for entries matching a full-text query, joining
+	// entry state with stateJoin. Parameters: highlight start, highlight
+	// end, query.
+	searchQuery(stateJoin string) string
+	// sizeBytes returns the database size and the part of it reclaimable by VACUUM.
+	sizeBytes(c *conn) (size, free int64, err error)
+	// integrityCheck returns the engine's own consistency report, or nil if it has none.
+	integrityCheck(c *conn) ([]string, error)
+	// searchIndexCheck counts index rows without an entry and entries without an index row.
+	searchIndexCheck(c *conn) (orphaned, missing int, err error)
+}
+
+// dialectFor picks the dialect for a --db value: PostgreSQL for postgres://
+// URLs, SQLite for file paths.
+func dialectFor(dsn string) dialect {
+	if IsPostgresDSN(dsn) {
+		return postgresDialect{}
+	}
+	return sqliteDialect{}
+}
+
+// IsPostgresDSN reports whether dsn is a PostgreSQL connection URL rather
+// than a SQLite file path.
+func IsPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// conn wraps *sql.DB, rebinding every query for the dialect.
+type conn struct {
+	*sql.DB
+	d dialect
+}
+
+func (c *conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.d.rebind(query), args...)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.ExecContext(ctx, c.d.rebind(query), args...)
+}
+
+func (c *conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.Query(c.d.rebind(query), args...)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.QueryContext(ctx, c.d.rebind(query), args...)
+}
+
+func (c *conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRow(c.d.rebind(query), args...)
+}
+
+func (c *conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRowContext(ctx, c.d.rebind(query), args...)
+}
+
+func (c *conn) Begin() (*txn, error) {
+	return c.BeginTx(context.Background(), nil)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*txn, error) {
+	tx, err := c.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, d: c.d}, nil
+}
+
+// txn wraps *sql.Tx, rebinding every query for the dialect.
+type txn struct {
+	*sql.Tx
+	d dialect
+}
+
+func (t *txn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.d.rebind(query), args...)
+}
+
+func (t *txn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.d.rebind(query), args...)
+}
+
+func (t *txn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.Query(t.d.rebind(query), args...)
+}
+
+func (t *txn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.d.rebind(query), args...)
+}
+
+func (t *txn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.d.rebind(query), args...)
+}
+
+func (t *txn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.Tx.PrepareContext(ctx, t.d.rebind(query))
+}
+
+// numberPlaceholders rewrites "?" and "?N" placeholders as "$N", leaving
+// quoted strings and identifiers alone.
+func numberPlaceholders(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	next := 1
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '?':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			b.WriteByte('$')
+			if j > i+1 {
+				b.WriteString(query[i+1 : j])
+			} else {
+				b.WriteString(strconv.Itoa(next))
+				next++
+			}
+			i = j - 1
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// sqliteDialect is the default, file-based engine.
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string       { return "sqlite" }
+func (sqliteDialect) driverName() string { return "sqlite" }
+
+// busyTimeout is how long a SQLite connection waits for another
+// connection's lock, such as the writer's open transaction or another
+// process's, before failing with SQLITE_BUSY.
+const busyTimeout = 5 * time.Second
+
+func (sqliteDialect) dataSource(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=busy_timeout(" + strconv.FormatInt(busyTimeout.Milliseconds(), 10) + ")"
+}
+
+func (sqliteDialect) rebind(query string) string { return query }
+
+func (sqliteDialect) migrations() []Migration { return migrations }
+
+func (sqliteDialect) schemaVersion(c *conn) (int, error) {
+	var version int
+	err := c.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+func (sqliteDialect) setSchemaVersion(version int) string {
+	// PRAGMA does not accept bound parameters; version is a trusted int
+	return "PRAGMA user_version = " + strconv.Itoa(version)
+}
+
+func (sqliteDialect) searchQuery(stateJoin string) string {
+	return "SELECT " + entryColumns + `,
+		snippet(entries_fts, -1, ?, ?, '…', 16),
+		bm25(entries_fts, 10.0, 1.0, 5.0) AS rank
+		FROM entries_fts JOIN entries ON entries.id = entries_fts.rowid` + stateJoin + `
+		WHERE entries_fts MATCH ?`
+}
+
+func (sqliteDialect) sizeBytes(c *conn) (int64, int64, error) {
+	var pageSize, pageCount, freePages int64
+	err := c.QueryRow("SELECT page_size, page_count, freelist_count FROM pragma_page_size, pragma_page_count, pragma_freelist_count").
+		Scan(&pageSize, &pageCount, &freePages)
+	return pageSize * pageCount, pageSize * freePages, err
+}
+
+func (sqliteDialect) integrityCheck(c *conn) ([]string, error) {
+	rows, err := c.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []string{}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+func (sqliteDialect) searchIndexCheck(c *conn) (int, int, error) {
+	var orphaned, missing int
+	err := c.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM entries_fts WHERE rowid NOT IN (SELECT id FROM entries)),
+			(SELECT COUNT(*) FROM entries WHERE id NOT IN (SELECT rowid FROM entries_fts))`,
+	).Scan(&orphaned, &missing)
+	return orphaned, missing, err
+}
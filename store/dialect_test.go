@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPostgresEnv names a PostgreSQL URL to run the store tests against
+// instead of in-memory SQLite. Its public schema is dropped before each test.
+const testPostgresEnv = "FEED_CLI_TEST_POSTGRES"
+
+// testingPostgres reports whether the tests run against PostgreSQL.
+func testingPostgres() bool {
+	return os.Getenv(testPostgresEnv) != ""
+}
+
+// openTestStore opens an empty, migrated store on the backend under test.
+func openTestStore(t *testing.T) (*Store, error) {
+	t.Helper()
+
+	dsn := os.Getenv(testPostgresEnv)
+	if dsn == "" {
+		return New(":memory:")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public")
+	require.NoError(t, err)
+
+	return New(dsn)
+}
+
+// skipOnPostgres skips tests of SQLite-only behaviour.
+func skipOnPostgres(t *testing.T) {
+	t.Helper()
+	if testingPostgres() {
+		t.Skip("SQLite only")
+	}
+}
+
+func TestNumberPlaceholders(t *testing.T) {
+	assert.Equal(t, "SELECT 1", numberPlaceholders("SELECT 1"))
+	assert.Equal(t,
+		"SELECT * FROM entries WHERE id = $1 AND title = '?' AND guid IN ($2, $3)",
+		numberPlaceholders("SELECT * FROM entries WHERE id = ? AND title = '?' AND guid IN (?, ?)"),
+	)
+	assert.Equal(t,
+		`SELECT $1, $2 WHERE "a?" = $1`,
+		numberPlaceholders(`SELECT ?1, ?2 WHERE "a?" = ?1`),
+	)
+}
+
+func TestPostgresMigrationsMatch(t *testing.T) {
+	require.Len(t, postgresMigrations, len(migrations))
+	for i, m := range migrations {
+		assert.Equal(t, m.Version, postgresMigrations[i].Version)
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	assert.Equal(t, "sqlite", dialectFor("/home/me/.config/feed-cli/feed-cli.db").name())
+	assert.Equal(t, "sqlite", dialectFor(":memory:").name())
+	assert.Equal(t, "postgres", dialectFor("postgres://feeds@db/feeds?sslmode=disable").name())
+	assert.Equal(t, "postgres", dialectFor("postgresql://db/feeds").name())
+}
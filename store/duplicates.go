@@ -0,0 +1,412 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robertmeta/feed-cli/feed"
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// Entries are duplicates when their links are the same after
+// feed.CanonicalLink, or when entries from different feeds published within
+// duplicateTitleWindow of each other have the same titleKey. A new entry
+// joins the group of the earliest stored entry it duplicates; the group ID
+// is the ID of the group's first entry. Entries stored together are grouped
+// together, after they are all inserted.
+
+// duplicateTitleWindow bounds how far apart entries matched by title alone
+// may have been published.
+const duplicateTitleWindow = 7 * 24 * time.Hour
+
+// minTitleWords is the fewest words a title needs to be matched on; short
+// titles such as "Weekly update" recur without being the same story.
+const minTitleWords = 4
+
+// siteSeparators set off the site name some feeds append to titles, as in
+// "Why we rewrote the parser – Example Blog".
+var siteSeparators = []string{" | ", " - ", " -- ", " – ", " — ", " · ", " :: ", " » "}
+
+// titleKey returns a title's words lowercased, without punctuation or markup
+// entities, for matching near-identical titles. A trailing segment that is
+// one of sites (see siteNames) is dropped; any other, such as " - part two",
+// tells titles apart. Hyphens and apostrophes inside a word are dropped, so
+// "re-write" and "rewrite" match. Titles shorter than minTitleWords return "".
+func titleKey(title string, sites []string) string {
+	title = html.UnescapeString(title)
+
+	cut, sepLen := -1, 0
+	for _, sep := range siteSeparators {
+		if i := strings.LastIndex(title, sep); i > cut {
+			cut, sepLen = i, len(sep)
+		}
+	}
+	if cut >= 0 && len(titleWords(title[:cut])) >= minTitleWords {
+		suffix := strings.Join(titleWords(title[cut+sepLen:]), " ")
+		for _, site := range sites {
+			if suffix == site {
+				title = title[:cut]
+				break
+			}
+		}
+	}
+
+	words := titleWords(title)
+	if len(words) < minTitleWords {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+// siteNames returns the names a feed's entries may append to their titles,
+// as titleWords joined by spaces: the feed's title, its host without "www."
+// and the host's second-level label ("example" for blog.example.com).
+func siteNames(feedTitle, feedURL string) []string {
+	names := []string{}
+	add := func(name string) {
+		if words := titleWords(name); len(words) > 0 {
+			names = append(names, strings.Join(words, " "))
+		}
+	}
+	add(feedTitle)
+	if u, err := url.Parse(feedURL); err == nil {
+		host := strings.TrimPrefix(u.Hostname(), "www.")
+		add(host)
+		if labels := strings.Split(host, "."); len(labels) >= 2 {
+			add(labels[len(labels)-2])
+		}
+	}
+	return names
+}
+
+// feedSiteNames returns siteNames for the feed with the given ID.
+func feedSiteNames(ctx context.Context, tx *txn, feedID int64) ([]string, error) {
+	var title, feedURL string
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(title, ''), url FROM feeds WHERE id = ?", feedID).Scan(&title, &feedURL)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get feed %d: %w", feedID, err)
+	}
+	return siteNames(title, feedURL), nil
+}
+
+// titleWords splits a title into lowercase words of letters and digits.
+func titleWords(title string) []string {
+	runes := []rune(strings.ToLower(title))
+	isWord := func(i int) bool {
+		return i >= 0 && i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case isWord(i):
+			b.WriteRune(r)
+		case (unicode.Is(unicode.Pd, r) || r == '\'' || r == '’') && isWord(i-1) && isWord(i+1):
+			// Joins the word
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// groupBatch bounds how many keys or rows one grouping statement binds.
+const groupBatch = 500
+
+// duplicateCandidate is a stored entry that may be the duplicate of a new one.
+type duplicateCandidate struct {
+	id, feedID, published int64
+	group                 int64 // COALESCE(group_id, id)
+	grouped               bool  // group_id is set
+}
+
+// groupEntries puts newly stored entries in the group of the earliest entry
+// each duplicates, if any. Candidates for the whole batch are looked up at
+// once, new entries are matched in the order they were stored and the groups
+// are written with one UPDATE.
+func groupEntries(ctx context.Context, tx *txn, entries []*model.Entry) error {
+	type newEntry struct {
+		*model.Entry
+		link, title string
+	}
+	batch := []newEntry{}
+	var links, titles []interface{}
+	seen := map[string]bool{}
+	sites := map[int64][]string{}
+	for _, e := range entries {
+		if _, ok := sites[e.FeedID]; !ok {
+			names, err := feedSiteNames(ctx, tx, e.FeedID)
+			if err != nil {
+				return err
+			}
+			sites[e.FeedID] = names
+		}
+		n := newEntry{e, feed.CanonicalLink(e.Link), titleKey(e.Title, sites[e.FeedID])}
+		if n.link == "" && n.title == "" {
+			continue
+		}
+		batch = append(batch, n)
+		if n.link != "" && !seen["l "+n.link] {
+			seen["l "+n.link] = true
+			links = append(links, n.link)
+		}
+		if n.title != "" && !seen["t "+n.title] {
+			seen["t "+n.title] = true
+			titles = append(titles, n.title)
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	byID, byLink, byTitle, err := duplicateCandidates(ctx, tx, links, titles)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID })
+	inBatch := map[int64]*model.Entry{}
+	for _, e := range batch {
+		inBatch[e.ID] = e.Entry
+	}
+	window := int64(duplicateTitleWindow / time.Second)
+	updates := map[int64]int64{}
+	for _, e := range batch {
+		var match *duplicateCandidate
+		if list := byLink[e.link]; len(list) > 0 && list[0].id < e.ID {
+			match = list[0]
+		}
+		published := e.Published.Unix()
+		for _, c := range byTitle[e.title] {
+			if c.id >= e.ID || (match != nil && c.id >= match.id) {
+				break
+			}
+			if c.feedID != e.FeedID && c.published >= published-window && c.published <= published+window {
+				match = c
+				break
+			}
+		}
+		if match == nil {
+			continue
+		}
+
+		if !match.grouped {
+			match.grouped = true
+			updates[match.id] = match.group
+			if first := inBatch[match.id]; first != nil {
+				first.GroupID = match.group
+			}
+		}
+		e.GroupID = match.group
+		updates[e.ID] = match.group
+		// Later entries in the batch may match this one
+		if c := byID[e.ID]; c != nil {
+			c.group, c.grouped = match.group, true
+		}
+	}
+
+	return setGroups(ctx, tx, updates)
+}
+
+// duplicateCandidates loads the stored entries with any of the given
+// canonical links or title keys, by ID and indexed by each key in ID order.
+func duplicateCandidates(ctx context.Context, tx *txn, links, titles []interface{}) (byID map[int64]*duplicateCandidate, byLink, byTitle map[string][]*duplicateCandidate, err error) {
+	byID = map[int64]*duplicateCandidate{}
+	byLink, byTitle = map[string][]*duplicateCandidate{}, map[string][]*duplicateCandidate{}
+
+	query := func(column string, keys []interface{}) error {
+		for start := 0; start < len(keys); start += groupBatch {
+			chunk := keys[start:min(start+groupBatch, len(keys))]
+			rows, err := tx.QueryContext(ctx,
+				"SELECT id, feed_id, canonical_link, title_key, published, group_id FROM entries WHERE "+column+" IN ("+placeholders(len(chunk))+")",
+				chunk...,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to find duplicates: %w", err)
+			}
+			for rows.Next() {
+				c := &duplicateCandidate{}
+				var link, title string
+				var group sql.NullInt64
+				if err := rows.Scan(&c.id, &c.feedID, &link, &title, &c.published, &group); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to scan duplicate: %w", err)
+				}
+				if byID[c.id] != nil {
+					continue // Matched both a link and a title
+				}
+				c.group, c.grouped = c.id, group.Valid
+				if group.Valid {
+					c.group = group.Int64
+				}
+				byID[c.id] = c
+				if link != "" {
+					byLink[link] = append(byLink[link], c)
+				}
+				if title != "" {
+					byTitle[title] = append(byTitle[title], c)
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("failed to find duplicates: %w", err)
+			}
+		}
+		return nil
+	}
+	if err := query("canonical_link", links); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := query("title_key", titles); err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, index := range []map[string][]*duplicateCandidate{byLink, byTitle} {
+		for _, list := range index {
+			sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+		}
+	}
+	return byID, byLink, byTitle, nil
+}
+
+// setGroups writes group IDs by entry ID.
+func setGroups(ctx context.Context, tx *txn, groups map[int64]int64) error {
+	ids := make([]int64, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for start := 0; start < len(ids); start += groupBatch {
+		chunk := ids[start:min(start+groupBatch, len(ids))]
+		cases := strings.Repeat(" WHEN ? THEN CAST(? AS BIGINT)", len(chunk))
+		args := make([]interface{}, 0, 3*len(chunk))
+		for _, id := range chunk {
+			args = append(args, id, groups[id])
+		}
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		_, err := tx.ExecContext(ctx,
+			"UPDATE entries SET group_id = CASE id"+cases+" END WHERE id IN ("+placeholders(len(chunk))+")",
+			args...,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to group entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillDuplicates computes the duplicate keys of existing entries and
+// groups them in the order they were stored, as if each had just arrived.
+func backfillDuplicates(tx *txn) error {
+	entries, err := updateDuplicateKeys(tx)
+	if err != nil {
+		return err
+	}
+	return groupEntries(context.Background(), tx, entries)
+}
+
+// refreshDuplicateKeys recomputes the duplicate keys of existing entries
+// after the way they are derived changed. Existing groups are kept; new
+// entries are matched against the new keys.
+func refreshDuplicateKeys(tx *txn) error {
+	_, err := updateDuplicateKeys(tx)
+	return err
+}
+
+// updateDuplicateKeys stores the current canonical link and title key of
+// every entry whose keys changed, and returns all entries in ID order.
+func updateDuplicateKeys(tx *txn) ([]*model.Entry, error) {
+	rows, err := tx.Query(`
+		SELECT e.id, e.feed_id, COALESCE(e.link, ''), COALESCE(e.title, ''), e.published, e.canonical_link, e.title_key,
+			COALESCE(f.title, ''), COALESCE(f.url, '')
+		FROM entries e LEFT JOIN feeds f ON f.id = e.feed_id
+		ORDER BY e.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entries: %w", err)
+	}
+	type keys struct{ link, title string }
+	entries := []*model.Entry{}
+	stored := map[int64]keys{}
+	sites := map[int64][]string{}
+	for rows.Next() {
+		e := &model.Entry{}
+		var published int64
+		var k keys
+		var feedTitle, feedURL string
+		if err := rows.Scan(&e.ID, &e.FeedID, &e.Link, &e.Title, &published, &k.link, &k.title, &feedTitle, &feedURL); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		e.Published = unixToTime(published)
+		entries = append(entries, e)
+		stored[e.ID] = k
+		if _, ok := sites[e.FeedID]; !ok {
+			sites[e.FeedID] = siteNames(feedTitle, feedURL)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		k := keys{feed.CanonicalLink(e.Link), titleKey(e.Title, sites[e.FeedID])}
+		if k == stored[e.ID] {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE entries SET canonical_link = ?, title_key = ? WHERE id = ?", k.link, k.title, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to update entry %d: %w", e.ID, err)
+		}
+	}
+	return entries, nil
+}
+
+// GetDuplicates returns the other entries in an entry's duplicate group, in
+// the order they were stored.
+func (s *Store) GetDuplicates(id int64) ([]*model.Entry, error) {
+	var group int64
+	err := s.db.QueryRow("SELECT COALESCE(group_id, id) FROM entries WHERE id = ?", id).Scan(&group)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("entry not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entry: %w", err)
+	}
+
+	rows, err := s.db.Query(
+		"SELECT "+entryColumns+" FROM entries"+s.stateJoin()+" WHERE (entries.group_id = ? OR entries.id = ?) AND entries.id <> ? ORDER BY entries.id",
+		group, group, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadTags(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
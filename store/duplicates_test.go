@@ -0,0 +1,275 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDuplicateStore stores the same story from a blog, an aggregator that
+// links it with tracking parameters and a site that only repeats its title,
+// plus an unrelated entry.
+func newDuplicateStore(t *testing.T) (*Store, map[string]*model.Entry) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	now := time.Now()
+	feeds := []*model.Feed{}
+	for _, url := range []string{"https://blog.example.com/rss", "https://news.example.com/rss", "https://hn.example.com/rss"} {
+		f := &model.Feed{URL: url}
+		require.NoError(t, s.SaveFeed(f))
+		feeds = append(feeds, f)
+	}
+
+	byGUID := map[string]*model.Entry{}
+	save := func(feed *model.Feed, e *model.Entry) {
+		e.Published = now.Add(-time.Hour)
+		_, err := s.SaveEntries(feed.ID, []*model.Entry{e})
+		require.NoError(t, err)
+		byGUID[e.GUID] = e
+	}
+	save(feeds[0], &model.Entry{GUID: "blog", Title: "Why we rewrote the parser", Link: "https://blog.example.com/parser/"})
+	save(feeds[1], &model.Entry{GUID: "news", Title: "Rewriting a parser", Link: "http://www.blog.example.com/parser?utm_source=news#comments"})
+	save(feeds[2], &model.Entry{GUID: "hn", Title: "Why We Rewrote the Parser!", Link: "https://hn.example.com/item?id=1"})
+	save(feeds[2], &model.Entry{GUID: "other", Title: "Something else entirely today", Link: "https://hn.example.com/item?id=2"})
+
+	return s, byGUID
+}
+
+func TestTitleKey(t *testing.T) {
+	assert.Equal(t, "why we rewrote the parser", titleKey("Why  we rewrote the Parser?!", nil))
+	assert.Equal(t, "tom jerry go to town", titleKey("Tom &amp; Jerry go to town", nil))
+	assert.Empty(t, titleKey("Weekly update", nil), "Short titles are not matched")
+
+	// The feed's own site names and spelling variants
+	sites := siteNames("The Example Blog", "https://www.example.com/feed")
+	assert.Equal(t, []string{"the example blog", "example com", "example"}, sites)
+	want := "why we rewrote the parser"
+	for _, title := range []string{
+		"Why we rewrote the parser – The Example Blog",
+		"Why we rewrote the parser | Example",
+		"Why we rewrote the parser -- example.com",
+		"Why we re-wrote the parser",
+		"Why we re‑wrote the parser — the example blog",
+	} {
+		assert.Equal(t, want, titleKey(title, sites), title)
+	}
+	assert.Equal(t, "dont panic about the parser", titleKey("Don’t panic about the parser", nil))
+	assert.Equal(t, titleKey("Don't panic about the parser", nil), titleKey("Don’t panic about the parser", nil))
+
+	// Not site names: other feeds' names, and titles that would be too short
+	assert.Equal(t, "why we rewrote the parser other blog", titleKey("Why we rewrote the parser | Other Blog", sites))
+	assert.Equal(t, "show hn parser example", titleKey("Show HN: Parser - Example", sites))
+}
+
+func TestTitleKey_KeepsOtherSuffixes(t *testing.T) {
+	sites := siteNames("Example Blog", "https://blog.example.com/rss")
+	for _, pair := range [][2]string{
+		{"Rust async explained - part one", "Rust async explained - part two"},
+		{"A tour of the parser: the good", "A tour of the parser: the bad"},
+		{"Weekly roundup of Go news - Issue 41", "Weekly roundup of Go news - Issue 42"},
+	} {
+		assert.NotEqual(t, titleKey(pair[0], sites), titleKey(pair[1], sites), pair[0])
+	}
+}
+
+func TestDuplicates_TitleWithSiteName(t *testing.T) {
+	s, entries := newDuplicateStore(t)
+	defer s.Close()
+
+	f := &model.Feed{URL: "https://eng.example.org/rss", Title: "Example Engineering"}
+	require.NoError(t, s.SaveFeed(f))
+	e := &model.Entry{GUID: "eng", Title: "Why we re-wrote the parser – Example Engineering", Link: "https://eng.example.org/1", Published: time.Now()}
+	_, err := s.SaveEntries(f.ID, []*model.Entry{e})
+	require.NoError(t, err)
+	assert.Equal(t, entries["blog"].ID, e.GroupID)
+
+	// A suffix that is not the feed's own name is part of the title
+	e = &model.Entry{GUID: "part", Title: "Why we rewrote the parser - part two", Link: "https://eng.example.org/2", Published: time.Now()}
+	_, err = s.SaveEntries(f.ID, []*model.Entry{e})
+	require.NoError(t, err)
+	assert.Zero(t, e.GroupID)
+}
+
+func TestDuplicates_Grouped(t *testing.T) {
+	s, entries := newDuplicateStore(t)
+	defer s.Close()
+
+	group := entries["blog"].ID
+	assert.Equal(t, group, entries["news"].GroupID, "Same canonical link")
+	assert.Equal(t, group, entries["hn"].GroupID, "Same title from another feed")
+	assert.Zero(t, entries["other"].GroupID)
+
+	dups, err := s.GetDuplicates(entries["hn"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blog", "news"}, entryGUIDs(dups))
+
+	dups, err = s.GetDuplicates(entries["other"].ID)
+	require.NoError(t, err)
+	assert.Empty(t, dups)
+
+	// Titles only match across feeds and within the window
+	f := &model.Feed{URL: "https://late.example.com/rss"}
+	require.NoError(t, s.SaveFeed(f))
+	late := &model.Entry{GUID: "late", Title: "Why we rewrote the parser", Published: time.Now().Add(-30 * 24 * time.Hour)}
+	_, err = s.SaveEntries(f.ID, []*model.Entry{late})
+	require.NoError(t, err)
+	assert.Zero(t, late.GroupID)
+}
+
+func TestDuplicates_GroupedWithinBatch(t *testing.T) {
+	s, entries := newDuplicateStore(t)
+	defer s.Close()
+
+	f := &model.Feed{URL: "https://mirror.example.com/rss"}
+	require.NoError(t, s.SaveFeed(f))
+	now := time.Now()
+	batch := []*model.Entry{
+		{GUID: "m1", Title: "A story nobody else has", Link: "https://mirror.example.com/new?utm_source=x", Published: now},
+		{GUID: "m2", Title: "Parser mirror", Link: "https://blog.example.com/parser", Published: now},
+		{GUID: "m3", Title: "The same story, reposted", Link: "https://mirror.example.com/new", Published: now},
+		{GUID: "m4", Title: "Something else entirely today", Link: "https://mirror.example.com/4", Published: now},
+	}
+	inserted, err := s.SaveEntries(f.ID, batch)
+	require.NoError(t, err)
+	require.Equal(t, 4, inserted)
+
+	assert.Equal(t, entries["blog"].ID, batch[1].GroupID, "Joins a stored group")
+	assert.Equal(t, batch[0].ID, batch[0].GroupID, "Starts a group within the batch")
+	assert.Equal(t, batch[0].ID, batch[2].GroupID)
+	assert.Equal(t, entries["other"].ID, batch[3].GroupID, "Same title from another feed")
+
+	for _, e := range batch {
+		stored, err := s.GetEntry(e.ID)
+		require.NoError(t, err)
+		assert.Equal(t, e.GroupID, stored.GroupID, e.GUID)
+	}
+	other, err := s.GetEntry(entries["other"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, other.GroupID)
+}
+
+func TestDuplicates_ListDedupe(t *testing.T) {
+	s, entries := newDuplicateStore(t)
+	defer s.Close()
+
+	list, err := s.GetEntries(QueryOptions{Dedupe: true, Sort: SortID, Order: SortAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blog", "other"}, entryGUIDs(list))
+
+	total, err := s.CountEntries(QueryOptions{Dedupe: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	// The representative is picked among matching entries
+	list, err = s.GetEntries(QueryOptions{Dedupe: true, FeedIDs: []int64{entries["hn"].FeedID}, Sort: SortID, Order: SortAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hn", "other"}, entryGUIDs(list))
+}
+
+func TestDuplicates_MarkReadMarksGroup(t *testing.T) {
+	s, entries := newDuplicateStore(t)
+	defer s.Close()
+
+	n, err := s.MarkEntriesRead([]int64{entries["news"].ID}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	unread, err := s.GetEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other"}, entryGUIDs(unread))
+
+	// Marking unread stays with the entries given
+	n, err = s.MarkEntriesRead([]int64{entries["hn"].ID}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Marking read by filter covers duplicates in other feeds
+	n, err = s.MarkReadWhere(QueryOptions{FeedIDs: []int64{entries["hn"].FeedID}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = s.MarkEntriesRead([]int64{entries["blog"].ID}, false)
+	require.NoError(t, err)
+	n, err = s.MarkReadWhere(QueryOptions{FeedIDs: []int64{entries["hn"].FeedID}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "Already read entries still carry the change to their duplicates")
+}
+
+func TestMigrate_BackfillsDuplicateGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "groups.db")
+
+	// A database from before duplicate groups
+	all := migrations
+	migrations = all[:12]
+	s, err := New(path)
+	migrations = all
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	_, err = s.db.Exec(`
+		INSERT INTO feeds (url) VALUES ('https://a.example.com/rss'), ('https://b.example.com/rss');
+		INSERT INTO entries (feed_id, guid, title, link, published)
+		VALUES (1, 'a', 'One', 'https://example.com/story', ?1),
+		       (2, 'b', 'Two', 'https://example.com/story/?utm_medium=rss', ?1),
+		       (2, 'c', 'Three', 'https://example.com/other', ?1);`,
+		now)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = New(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	list, err := s.GetEntries(QueryOptions{Sort: SortID, Order: SortAsc})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, list[0].ID, list[0].GroupID)
+	assert.Equal(t, list[0].ID, list[1].GroupID)
+	assert.Zero(t, list[2].GroupID)
+
+	// New entries join groups found by the backfill
+	e := &model.Entry{GUID: "d", Title: "Four", Link: "https://www.example.com/story", Published: time.Now()}
+	_, err = s.SaveEntries(1, []*model.Entry{e})
+	require.NoError(t, err)
+	assert.Equal(t, list[0].ID, e.GroupID)
+}
+
+func TestMigrate_RefreshesDuplicateKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.db")
+
+	// A database keyed before site names were dropped
+	all := migrations
+	migrations = all[:16]
+	s, err := New(path)
+	migrations = all
+	require.NoError(t, err)
+
+	f := &model.Feed{URL: "https://blog.example.com/rss", Title: "Example Blog"}
+	require.NoError(t, s.SaveFeed(f))
+	stored := &model.Entry{GUID: "a", Title: "Why we rewrote the parser | Example Blog", Link: "https://blog.example.com/parser", Published: time.Now()}
+	_, err = s.SaveEntries(f.ID, []*model.Entry{stored})
+	require.NoError(t, err)
+	_, err = s.db.Exec("UPDATE entries SET title_key = 'why we rewrote the parser example blog' WHERE id = ?", stored.ID)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = New(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	var key string
+	require.NoError(t, s.db.QueryRow("SELECT title_key FROM entries WHERE id = ?", stored.ID).Scan(&key))
+	assert.Equal(t, "why we rewrote the parser", key)
+
+	other := &model.Feed{URL: "https://news.example.com/rss"}
+	require.NoError(t, s.SaveFeed(other))
+	e := &model.Entry{GUID: "b", Title: "Why we rewrote the parser", Link: "https://news.example.com/1", Published: time.Now()}
+	_, err = s.SaveEntries(other.ID, []*model.Entry{e})
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, e.GroupID)
+}
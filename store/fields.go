@@ -0,0 +1,189 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// EntryFields are the names QueryOptions.Fields accepts, in output order.
+// They match the JSON names of model.Entry.
+var EntryFields = []string{"id", "feed_id", "guid", "title", "link", "original_link", "content", "author", "published", "is_read", "read_at", "starred_at", "tags", "group_id"}
+
+// entryFieldColumns maps the fields read from entries and entry_state to
+// their columns. Content and tags come from other tables and are loaded after
+// the query.
+var entryFieldColumns = map[string]string{
+	"id":            "entries.id",
+	"feed_id":       "entries.feed_id",
+	"guid":          "entries.guid",
+	"title":         "COALESCE(entries.title, '')",
+	"link":          "entries.link",
+	"original_link": "entries.original_link",
+	"author":        "COALESCE(entries.author, '')",
+	"published":     "entries.published",
+	"is_read":       "COALESCE(st.is_read, 0)",
+	"read_at":       "st.read_at",
+	"starred_at":    "st.starred_at",
+	"group_id":      "COALESCE(entries.group_id, 0)",
+}
+
+// sortFields is the field holding each sort key, which cursors need.
+var sortFields = map[SortField]string{
+	SortPublished: "published",
+	SortTitle:     "title",
+	SortFeed:      "feed_id",
+	SortID:        "id",
+	SortRead:      "read_at",
+}
+
+// validateFields rejects unknown names in Fields.
+func (o QueryOptions) validateFields() error {
+	for _, f := range o.Fields {
+		known := false
+		for _, name := range EntryFields {
+			if f == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid field: %s (expected any of %s)", f, strings.Join(EntryFields, ", "))
+		}
+	}
+	return nil
+}
+
+// wants reports whether field is to be loaded. Without Fields every field
+// but content is; content needs WithContent or naming it.
+func (o QueryOptions) wants(field string) bool {
+	if field == "content" && o.WithContent {
+		return true
+	}
+	if len(o.Fields) == 0 {
+		return field != "content"
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// projection returns the select list and scanner for the fields in o. The ID
+// and the sort key are always selected so pages can hand out cursors. Queries
+// selecting it join the current user's state with stateJoin.
+func (o QueryOptions) projection() (string, func(rowScanner) (*model.Entry, error), error) {
+	if len(o.Fields) == 0 {
+		return entryColumns, func(row rowScanner) (*model.Entry, error) { return scanEntry(row) }, nil
+	}
+
+	field, _, err := o.sortSpec()
+	if err != nil {
+		return "", nil, err
+	}
+
+	selected := []string{}
+	columns := []string{}
+	for _, f := range EntryFields {
+		column, ok := entryFieldColumns[f]
+		if ok && (o.wants(f) || f == "id" || f == sortFields[field]) {
+			selected = append(selected, f)
+			columns = append(columns, column)
+		}
+	}
+
+	scan := func(row rowScanner) (*model.Entry, error) {
+		e := &model.Entry{}
+		var published int64
+		var isRead int
+		var readAt, starredAt sql.NullInt64
+
+		dest := make([]interface{}, 0, len(selected))
+		for _, f := range selected {
+			switch f {
+			case "id":
+				dest = append(dest, &e.ID)
+			case "feed_id":
+				dest = append(dest, &e.FeedID)
+			case "guid":
+				dest = append(dest, &e.GUID)
+			case "title":
+				dest = append(dest, &e.Title)
+			case "link":
+				dest = append(dest, &e.Link)
+			case "original_link":
+				dest = append(dest, &e.OriginalLink)
+			case "author":
+				dest = append(dest, &e.Author)
+			case "published":
+				dest = append(dest, &published)
+			case "is_read":
+				dest = append(dest, &isRead)
+			case "read_at":
+				dest = append(dest, &readAt)
+			case "starred_at":
+				dest = append(dest, &starredAt)
+			case "group_id":
+				dest = append(dest, &e.GroupID)
+			}
+		}
+		if err := row.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		if published != 0 {
+			e.Published = unixToTime(published)
+		}
+		e.IsRead = intToBool(isRead)
+		e.ReadAt = nullUnixToTime(readAt)
+		e.StarredAt = nullUnixToTime(starredAt)
+		return e, nil
+	}
+
+	return strings.Join(columns, ", "), scan, nil
+}
+
+// loadContent fills in the content of each entry.
+func (s *Store) loadContent(entries []*model.Entry) error {
+	for start := 0; start < len(entries); start += loadTagsBatch {
+		end := start + loadTagsBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := s.loadContentChunk(entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) loadContentChunk(entries []*model.Entry) error {
+	byID := make(map[int64]*model.Entry, len(entries))
+	args := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+		args = append(args, e.ID)
+	}
+
+	rows, err := s.db.Query("SELECT entry_id, content FROM entry_content WHERE entry_id IN ("+placeholders(len(entries))+")", args...)
+	if err != nil {
+		return fmt.Errorf("failed to query entry content: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID int64
+		var content string
+		if err := rows.Scan(&entryID, &content); err != nil {
+			return fmt.Errorf("failed to scan entry content: %w", err)
+		}
+		if e, ok := byID[entryID]; ok {
+			e.Content = content
+		}
+	}
+	return rows.Err()
+}
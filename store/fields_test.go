@@ -0,0 +1,85 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEntries_ContentIsOptIn(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+	_, err = s.SaveEntries(feed.ID, []*model.Entry{
+		{GUID: "a", Title: "A", Content: "<p>Body</p>", Published: time.Now()},
+		{GUID: "b", Title: "B", Published: time.Now().Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+
+	entries, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Empty(t, entries[0].Content)
+
+	entries, err = s.GetEntries(QueryOptions{WithContent: true})
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Body</p>", entries[0].Content)
+	assert.Empty(t, entries[1].Content)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Body</p>", entry.Content, "Single entries always have content")
+
+	// Clearing content leaves an empty row
+	entry.Content = ""
+	require.NoError(t, s.SaveEntry(entry))
+	entry, err = s.GetEntry(entry.ID)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Content)
+}
+
+func TestGetEntries_Fields(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+	_, err := s.TagEntries([]int64{4}, "go")
+	require.NoError(t, err)
+
+	entries, err := s.GetEntries(QueryOptions{Fields: []string{"title"}})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	e := entries[0]
+	assert.Equal(t, "gamma", e.Title)
+	assert.NotZero(t, e.ID, "The ID is always selected")
+	assert.False(t, e.Published.IsZero(), "The sort key is always selected")
+	assert.Empty(t, e.GUID)
+	assert.Zero(t, e.FeedID)
+	assert.Nil(t, e.Tags)
+
+	entries, err = s.GetEntries(QueryOptions{Fields: []string{"guid", "tags", "is_read"}, Sort: SortTitle, Order: SortAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a2", "a1", "b1", "b2"}, entryGUIDs(entries))
+	assert.True(t, entries[1].IsRead)
+	assert.Equal(t, []string{"go"}, entries[3].Tags)
+	assert.True(t, entries[0].Published.IsZero())
+
+	_, err = s.GetEntries(QueryOptions{Fields: []string{"title", "body"}})
+	assert.ErrorContains(t, err, "invalid field: body")
+	assert.Error(t, QueryOptions{Fields: []string{"body"}}.Validate())
+
+	// Cursors work with any projection
+	opts := QueryOptions{Fields: []string{"link"}, Sort: SortTitle, Order: SortAsc, Limit: 2}
+	page, err := s.ListEntries(opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+	opts.Cursor = page.NextCursor
+	page, err = s.ListEntries(opts)
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 2)
+	assert.Equal(t, "delta", page.Entries[0].Title)
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/robertmeta/feed-cli/model"
+	"golang.org/x/net/html"
+	"modernc.org/sqlite"
+)
+
+// Snippet highlight markers returned by Search.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// SearchResult is an entry matched by Search, with a highlighted snippet and
+// its rank (lower is more relevant).
+type SearchResult struct {
+	*model.Entry
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
+
+func init() {
+	// The FTS triggers index plain text, so every connection needs strip_html.
+	sqlite.MustRegisterDeterministicScalarFunction("strip_html", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return StripHTML(v), nil
+		case []byte:
+			return StripHTML(string(v)), nil
+		default:
+			return v, nil
+		}
+	})
+}
+
+// StripHTML converts HTML to plain text, dropping tags, scripts and styles.
+func StripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	skip := 0 // Depth inside <script>/<style>
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return strings.Join(strings.Fields(b.String()), " ")
+			}
+			return s
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := string(name); tag == "script" || tag == "style" {
+				skip++
+			}
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := string(name); (tag == "script" || tag == "style") && skip > 0 {
+				skip--
+			}
+			b.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			b.WriteByte(' ')
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// Search runs a full-text query over entry titles, plain-text content and
+// authors. On SQLite query uses FTS5 syntax: phrases ("go modules"), prefixes
+// (sched*) and boolean operators (rust AND NOT crypto); on PostgreSQL it uses
+// websearch syntax ("go modules", rust -crypto, go or rust). Results are
+// ranked with titles weighted above authors and content. The filters, Limit
+// and Offset from opts are applied as in GetEntries; Sort and Order are
+// ignored.
+func (s *Store) Search(query string, opts QueryOptions) ([]*SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	sqlQuery := s.db.d.searchQuery(s.stateJoin())
+	args := []interface{}{HighlightStart, HighlightEnd, query}
+
+	where, filterArgs, err := opts.filter(s.userID)
+	if err != nil {
+		return nil, err
+	}
+	sqlQuery += where + " ORDER BY rank, entries.published DESC"
+	sqlQuery, args = opts.paginate(sqlQuery, append(args, filterArgs...))
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search entries: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*SearchResult{}
+	for rows.Next() {
+		result := &SearchResult{}
+		entry, err := scanEntry(rows, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		// The snippet stands in for the (potentially large) HTML content,
+		// which entryColumns leaves out
+		result.Entry = entry
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search entries: %w", err)
+	}
+
+	entries := make([]*model.Entry, len(results))
+	for i, r := range results {
+		entries[i] = r.Entry
+	}
+	if err := s.loadTags(entries); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
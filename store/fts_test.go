@@ -0,0 +1,181 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSearchStore creates a store with a handful of entries to search.
+func newSearchStore(t *testing.T) (*Store, []*model.Entry) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	now := time.Now()
+	entries := []*model.Entry{
+		{GUID: "1", Title: "Go modules explained", Content: "<p>How <b>dependency</b> management works.</p>", Author: "Alice", Published: now},
+		{GUID: "2", Title: "Rust ownership", Content: "<p>Borrowing and the <em>scheduler</em> of your mind.</p>", Author: "Bob", Published: now.Add(-time.Hour), IsRead: true},
+		{GUID: "3", Title: "Weekly links", Content: "<script>var go = 1;</script><p>Rust and crypto news, plus go modules.</p>", Author: "Carol", Published: now.Add(-48 * time.Hour)},
+	}
+	for _, e := range entries {
+		e.FeedID = feed.ID
+		require.NoError(t, s.SaveEntry(e))
+	}
+
+	return s, entries
+}
+
+func searchGUIDs(results []*SearchResult) []string {
+	guids := []string{}
+	for _, r := range results {
+		guids = append(guids, r.GUID)
+	}
+	return guids
+}
+
+func TestStripHTML(t *testing.T) {
+	assert.Equal(t, "Hello world & more", StripHTML("<p>Hello <b>world</b> &amp; more</p>"))
+	assert.Equal(t, "visible", StripHTML("<style>p{}</style><script>alert(1)</script>visible"))
+	assert.Equal(t, "plain text", StripHTML("plain text"))
+}
+
+func TestSearch_QuerySyntax(t *testing.T) {
+	skipOnPostgres(t) // FTS5 syntax; see TestSearch_WebsearchSyntax
+	s, _ := newSearchStore(t)
+	defer s.Close()
+
+	// Phrase query; title matches rank above content matches
+	results, err := s.Search(`"go modules"`, QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, searchGUIDs(results))
+
+	// Prefix query
+	results, err = s.Search("sched*", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, searchGUIDs(results))
+
+	// Boolean query
+	results, err = s.Search("rust NOT crypto", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, searchGUIDs(results))
+
+	// Author column
+	results, err = s.Search("author:carol", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, searchGUIDs(results))
+
+	// HTML markup and scripts are not indexed
+	results, err = s.Search("var", QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearch_WebsearchSyntax(t *testing.T) {
+	if !testingPostgres() {
+		t.Skip("PostgreSQL only")
+	}
+	s, _ := newSearchStore(t)
+	defer s.Close()
+
+	results, err := s.Search(`"go modules"`, QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, searchGUIDs(results))
+
+	results, err = s.Search("rust -crypto", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, searchGUIDs(results))
+
+	results, err = s.Search("carol", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, searchGUIDs(results))
+
+	results, err = s.Search("var", QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearch_SnippetAndFilters(t *testing.T) {
+	s, _ := newSearchStore(t)
+	defer s.Close()
+
+	results, err := s.Search("dependency", QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Snippet, HighlightStart+"dependency"+HighlightEnd)
+	assert.Empty(t, results[0].Content, "Search results carry a snippet instead of content")
+
+	// Unread filter
+	results, err = s.Search("rust", QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, searchGUIDs(results))
+
+	// Since filter
+	since := time.Now().Add(-2 * time.Hour).Unix()
+	results, err = s.Search("rust", QueryOptions{SinceTime: &since})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, searchGUIDs(results))
+
+	// Pagination
+	results, err = s.Search("go OR rust", QueryOptions{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestSearch_IndexStaysInSync(t *testing.T) {
+	s, entries := newSearchStore(t)
+	defer s.Close()
+
+	// Update
+	entries[0].Title = "Generics deep dive"
+	require.NoError(t, s.SaveEntry(entries[0]))
+
+	results, err := s.Search("generics", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, searchGUIDs(results))
+
+	results, err = s.Search(`"modules explained"`, QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results, "Old title should no longer match")
+
+	// Entries without content are indexed by title, then by content once it arrives
+	bare := &model.Entry{GUID: "4", Title: "Notes on caching", Published: time.Now()}
+	_, err = s.SaveEntries(entries[0].FeedID, []*model.Entry{bare})
+	require.NoError(t, err)
+	results, err = s.Search("caching", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4"}, searchGUIDs(results))
+
+	bare.Content = "<p>Invalidation is hard</p>"
+	require.NoError(t, s.SaveEntry(bare))
+	results, err = s.Search("invalidation", QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4"}, searchGUIDs(results))
+
+	// Delete
+	_, err = s.db.Exec("DELETE FROM entries WHERE id = ?", entries[1].ID)
+	require.NoError(t, err)
+
+	results, err = s.Search("ownership", QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	s, _ := newSearchStore(t)
+	defer s.Close()
+
+	// websearch_to_tsquery accepts any input
+	if !testingPostgres() {
+		_, err := s.Search(`"unterminated`, QueryOptions{})
+		assert.Error(t, err)
+	}
+
+	_, err := s.Search("   ", QueryOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DailyReads is the number of entries marked read on one local calendar day.
+type DailyReads struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Read int    `json:"read"`
+}
+
+// FeedReads compares how many of a feed's entries were read with how many it
+// published over the same period.
+type FeedReads struct {
+	FeedID    int64   `json:"feed_id"`
+	Title     string  `json:"title"`
+	Read      int     `json:"read"`
+	Published int     `json:"published"`
+	ReadRatio float64 `json:"read_ratio"` // Read / Published; 0 when nothing was published
+}
+
+// ReadingStats summarises reading activity since a point in time.
+type ReadingStats struct {
+	Since  *time.Time    `json:"since,omitempty"`
+	Read   int           `json:"read"`
+	ByDay  []*DailyReads `json:"by_day"`
+	ByFeed []*FeedReads  `json:"by_feed"`
+}
+
+// GetReadingStats counts entries the current user marked read since the
+// given Unix time (all time when nil), per local day and per feed. Every feed is listed, so
+// subscriptions that are never read show up with zero reads, most read first.
+// Entries read before read times were recorded are not counted.
+func (s *Store) GetReadingStats(since *int64) (*ReadingStats, error) {
+	var from int64
+	stats := &ReadingStats{ByDay: []*DailyReads{}, ByFeed: []*FeedReads{}}
+	if since != nil {
+		from = *since
+		t := unixToTime(from)
+		stats.Since = &t
+	}
+
+	// Days are bucketed here rather than in SQL, where local-time date
+	// functions differ between SQLite and PostgreSQL
+	rows, err := s.db.Query("SELECT read_at FROM entry_state WHERE user_id = ? AND read_at >= ? ORDER BY read_at DESC", s.userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reads per day: %w", err)
+	}
+	var day *DailyReads
+	for rows.Next() {
+		var readAt int64
+		if err := rows.Scan(&readAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan reads per day: %w", err)
+		}
+		date := unixToTime(readAt).Format("2006-01-02")
+		if day == nil || day.Date != date {
+			day = &DailyReads{Date: date}
+			stats.ByDay = append(stats.ByDay, day)
+		}
+		day.Read++
+		stats.Read++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query reads per day: %w", err)
+	}
+
+	rows, err = s.db.Query(`
+		SELECT feeds.id, feeds.title, COALESCE(e.read, 0), COALESCE(e.published, 0)
+		FROM feeds LEFT JOIN (
+			SELECT entries.feed_id,
+				SUM(CASE WHEN st.read_at >= ?1 THEN 1 ELSE 0 END) AS read,
+				SUM(CASE WHEN entries.published >= ?1 THEN 1 ELSE 0 END) AS published
+			FROM entries`+s.stateJoin()+`
+			WHERE st.read_at >= ?1 OR entries.published >= ?1
+			GROUP BY entries.feed_id
+		) e ON e.feed_id = feeds.id
+		ORDER BY COALESCE(e.read, 0) DESC, feeds.id`, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reads per feed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := &FeedReads{}
+		if err := rows.Scan(&f.FeedID, &f.Title, &f.Read, &f.Published); err != nil {
+			return nil, fmt.Errorf("failed to scan reads per feed: %w", err)
+		}
+		if f.Published > 0 {
+			f.ReadRatio = math.Round(float64(f.Read)/float64(f.Published)*100) / 100
+		}
+		stats.ByFeed = append(stats.ByFeed, f)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkEntriesRead(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	ids := []int64{entries[0].ID, entries[1].ID}
+	marked, err := s.MarkEntriesRead(ids, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.True(t, entry.IsRead)
+	require.NotNil(t, entry.ReadAt)
+	firstRead := *entry.ReadAt
+
+	// Marking read again changes nothing and keeps the original read time
+	marked, err = s.MarkEntriesRead(ids, true)
+	require.NoError(t, err)
+	assert.Zero(t, marked)
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.True(t, entry.ReadAt.Equal(firstRead))
+
+	marked, err = s.MarkEntriesRead([]int64{entries[0].ID, entries[2].ID}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.False(t, entry.IsRead)
+	assert.Nil(t, entry.ReadAt)
+}
+
+func TestHistory(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	// a read two hours ago, b ten days ago, c unread
+	now := time.Now()
+	for i, ago := range []time.Duration{2 * time.Hour, 10 * 24 * time.Hour} {
+		readAt := now.Add(-ago)
+		entries[i].IsRead = true
+		entries[i].ReadAt = &readAt
+		require.NoError(t, s.SaveEntry(entries[i]))
+	}
+
+	page, err := s.ListEntries(QueryOptions{Sort: SortRead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, entryGUIDs(page.Entries), "Most recently read first, unread excluded")
+
+	week := now.Add(-7 * 24 * time.Hour).Unix()
+	page, err = s.ListEntries(QueryOptions{Sort: SortRead, ReadSince: &week})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, entryGUIDs(page.Entries))
+
+	// Cursor paging by read time
+	assert.Equal(t, []string{"a", "b"}, collectPages(t, s, QueryOptions{Sort: SortRead, Limit: 1}, nil))
+}
+
+func TestGetReadingStats(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.MarkEntriesRead([]int64{entries[0].ID, entries[1].ID}, true)
+	require.NoError(t, err)
+
+	since := time.Now().Add(-7 * 24 * time.Hour).Unix()
+	stats, err := s.GetReadingStats(&since)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Read)
+	require.Len(t, stats.ByDay, 1)
+	assert.Equal(t, time.Now().Format("2006-01-02"), stats.ByDay[0].Date)
+	assert.Equal(t, 2, stats.ByDay[0].Read)
+
+	require.Len(t, stats.ByFeed, 1)
+	assert.Equal(t, 2, stats.ByFeed[0].Read)
+	assert.Equal(t, 3, stats.ByFeed[0].Published)
+	assert.Equal(t, 0.67, stats.ByFeed[0].ReadRatio)
+}
+
+func TestMarkReadWhere(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	unreadGUIDs := func() []string {
+		entries, err := s.GetEntries(QueryOptions{Read: ReadUnread})
+		require.NoError(t, err)
+		return entryGUIDs(entries)
+	}
+
+	// Only entries whose state changes are counted
+	marked, err := s.MarkReadWhere(QueryOptions{Categories: []string{"golang"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+	assert.Equal(t, []string{"a2"}, unreadGUIDs())
+
+	marked, err = s.MarkReadWhere(QueryOptions{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, marked)
+	assert.Equal(t, []string{"b2", "b1", "a2", "a1"}, unreadGUIDs())
+
+	before := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC).Unix()
+	marked, err = s.MarkReadWhere(QueryOptions{Before: &before}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+	assert.Equal(t, []string{"b2", "b1"}, unreadGUIDs())
+
+	entries, err := s.GetEntries(QueryOptions{Sort: SortID, Order: SortAsc})
+	require.NoError(t, err)
+	marked, err = s.MarkReadWhere(QueryOptions{OlderThanID: entries[3].ID}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+	assert.Equal(t, []string{"b2"}, unreadGUIDs())
+
+	_, err = s.MarkReadWhere(QueryOptions{Read: "bogus"}, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// BackupResult describes a completed backup.
+type BackupResult struct {
+	Path          string `json:"path"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SchemaVersion int    `json:"schema_version"`
+	DurationMS    int64  `json:"duration_ms"`
+}
+
+// RestoreResult describes a completed restore.
+type RestoreResult struct {
+	Source         string `json:"source"`
+	Path           string `json:"path"`
+	BackupVersion  int    `json:"backup_version"`
+	CurrentVersion int    `json:"current_version"`
+}
+
+// ForeignKeyViolation counts rows in Table whose reference to Parent is dangling.
+type ForeignKeyViolation struct {
+	Table  string `json:"table"`
+	Parent string `json:"parent"`
+	Rows   int    `json:"rows"`
+}
+
+// CheckResult is the outcome of Check.
+type CheckResult struct {
+	OK                     bool                   `json:"ok"`
+	Integrity              []string               `json:"integrity"`
+	ForeignKeyViolations   []*ForeignKeyViolation `json:"foreign_key_violations"`
+	IndexedWithoutEntry    int                    `json:"fts_rows_without_entry"`
+	EntriesWithoutIndex    int                    `json:"entries_without_fts_row"`
+	DetachedStarredEntries int                    `json:"detached_starred_entries"` // Starred, annotated or queued, kept after their feed was removed
+}
+
+// VacuumResult reports the database size before and after VACUUM.
+type VacuumResult struct {
+	BeforeBytes int64 `json:"before_bytes"`
+	AfterBytes  int64 `json:"after_bytes"`
+	DurationMS  int64 `json:"duration_ms"`
+}
+
+// ErrUnsupported is returned for maintenance tasks the database engine
+// handles with its own tools, such as backing up PostgreSQL with pg_dump.
+var ErrUnsupported = errors.New("not supported on PostgreSQL; use pg_dump and pg_restore")
+
+// Backup writes a consistent copy of the database to path with VACUUM INTO.
+// It runs online: concurrent writers wait at most for the copy to finish, and
+// the copy never contains a half-written transaction. path must not exist.
+// SQLite only.
+func (s *Store) Backup(path string) (*BackupResult, error) {
+	if s.db.d.name() != "sqlite" {
+		return nil, ErrUnsupported
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup file already exists: %s", path)
+	}
+
+	start := time.Now()
+	if _, err := s.db.Exec("VACUUM INTO ?", path); err != nil {
+		return nil, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackupResult{
+		Path:          path,
+		SizeBytes:     info.Size(),
+		SchemaVersion: version,
+		DurationMS:    time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// Restore replaces the database at dbPath with the backup at backupPath and
+// migrates it to the current schema. The backup must pass an integrity check.
+// The replacement is an atomic rename; other feed-cli processes using dbPath
+// should be stopped first, or their writes are lost. SQLite only.
+func Restore(backupPath, dbPath string) (*RestoreResult, error) {
+	if IsPostgresDSN(backupPath) || IsPostgresDSN(dbPath) {
+		return nil, ErrUnsupported
+	}
+
+	// Open would create a missing file
+	if _, err := os.Stat(backupPath); err != nil {
+		return nil, fmt.Errorf("backup not found: %w", err)
+	}
+
+	src, err := Open(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer src.Close()
+
+	integrity, err := src.db.d.integrityCheck(src.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check backup: %w", err)
+	}
+	if len(integrity) != 1 || integrity[0] != "ok" {
+		return nil, fmt.Errorf("backup failed integrity check: %v", integrity)
+	}
+
+	backupVersion, err := src.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy next to the destination so the final rename stays on one filesystem
+	tmp := dbPath + ".restore"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale restore file: %w", err)
+	}
+	if _, err := src.db.Exec("VACUUM INTO ?", tmp); err != nil {
+		return nil, fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to replace database: %w", err)
+	}
+
+	// A leftover journal belongs to the replaced file and must not be replayed
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+
+	restored, err := New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open restored database: %w", err)
+	}
+	defer restored.Close()
+
+	currentVersion, err := restored.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RestoreResult{
+		Source:         backupPath,
+		Path:           dbPath,
+		BackupVersion:  backupVersion,
+		CurrentVersion: currentVersion,
+	}, nil
+}
+
+// Check runs the engine's integrity check (SQLite's integrity_check; invalid
+// indexes and, with amcheck installed, B-tree checks on PostgreSQL), looks for dangling references between tables and for
+// full-text index rows out of sync with entries. References are declared but
+// not enforced, so dangling ones are reported rather than prevented. Starred
+// entries kept after their feed was removed are expected and are counted
+// separately instead of as violations.
+func (s *Store) Check() (*CheckResult, error) {
+	result := &CheckResult{ForeignKeyViolations: []*ForeignKeyViolation{}}
+
+	integrity, err := s.db.d.integrityCheck(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	if integrity == nil {
+		integrity = []string{}
+	}
+	result.Integrity = integrity
+
+	rows, err := s.db.Query(`
+		SELECT 'entries', 'feeds', COUNT(*) FROM entries
+			WHERE feed_id NOT IN (SELECT id FROM feeds) AND id NOT IN (` + keptEntries + `)
+		UNION ALL
+		SELECT 'entry_content', 'entries', COUNT(*) FROM entry_content
+			WHERE entry_id NOT IN (SELECT id FROM entries)
+		UNION ALL
+		SELECT 'entry_state', 'entries', COUNT(*) FROM entry_state
+			WHERE entry_id NOT IN (SELECT id FROM entries)
+		UNION ALL
+		SELECT 'entry_state', 'users', COUNT(*) FROM entry_state
+			WHERE user_id NOT IN (SELECT id FROM users)
+		UNION ALL
+		SELECT 'entry_tags', 'entries', COUNT(*) FROM entry_tags
+			WHERE entry_id NOT IN (SELECT id FROM entries)
+		UNION ALL
+		SELECT 'entry_tags', 'tags', COUNT(*) FROM entry_tags
+			WHERE tag_id NOT IN (SELECT id FROM tags)
+		UNION ALL
+		SELECT 'notes', 'entries', COUNT(*) FROM notes
+			WHERE entry_id NOT IN (SELECT id FROM entries)
+		UNION ALL
+		SELECT 'highlights', 'entries', COUNT(*) FROM highlights
+			WHERE entry_id NOT IN (SELECT id FROM entries)
+		UNION ALL
+		SELECT 'queue_entries', 'entries', COUNT(*) FROM queue_entries
+			WHERE entry_id NOT IN (SELECT id FROM entries)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	for rows.Next() {
+		v := &ForeignKeyViolation{}
+		if err := rows.Scan(&v.Table, &v.Parent, &v.Rows); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign key check: %w", err)
+		}
+		if v.Rows > 0 {
+			result.ForeignKeyViolations = append(result.ForeignKeyViolations, v)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+
+	result.IndexedWithoutEntry, result.EntriesWithoutIndex, err = s.db.d.searchIndexCheck(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check search index: %w", err)
+	}
+
+	err = s.db.QueryRow("SELECT COUNT(*) FROM entries WHERE feed_id NOT IN (SELECT id FROM feeds) AND id IN (" + keptEntries + ")").
+		Scan(&result.DetachedStarredEntries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count detached entries: %w", err)
+	}
+
+	result.OK = (len(integrity) == 0 || len(integrity) == 1 && integrity[0] == "ok") &&
+		len(result.ForeignKeyViolations) == 0 &&
+		result.IndexedWithoutEntry == 0 && result.EntriesWithoutIndex == 0
+	return result, nil
+}
+
+// Vacuum rebuilds the database file, reclaiming space freed by deletes.
+func (s *Store) Vacuum() (*VacuumResult, error) {
+	before, err := s.sizeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	after, err := s.sizeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &VacuumResult{BeforeBytes: before, AfterBytes: after, DurationMS: time.Since(start).Milliseconds()}, nil
+}
+
+// Analyze refreshes the statistics the query planner uses to pick indexes.
+func (s *Store) Analyze() error {
+	if _, err := s.db.Exec("ANALYZE"); err != nil {
+		return fmt.Errorf("failed to analyze database: %w", err)
+	}
+	return nil
+}
+
+// sizeBytes returns the database size.
+func (s *Store) sizeBytes() (int64, error) {
+	size, _, err := s.pageStats()
+	return size, err
+}
+
+// pageStats returns the database size and the bytes reclaimable by VACUUM.
+func (s *Store) pageStats() (size, free int64, err error) {
+	size, free, err = s.db.d.sizeBytes(s.db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query database size: %w", err)
+	}
+	return size, free, nil
+}
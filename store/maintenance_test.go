@@ -0,0 +1,116 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "feed-cli.db")
+	backupPath := filepath.Join(dir, "backup.db")
+
+	s, err := New(dbPath)
+	require.NoError(t, err)
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Before"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	result, err := s.Backup(backupPath)
+	require.NoError(t, err)
+	assert.Positive(t, result.SizeBytes)
+	assert.Equal(t, LatestVersion(), result.SchemaVersion)
+
+	_, err = s.Backup(backupPath)
+	assert.Error(t, err, "Backup must not overwrite an existing file")
+
+	// Changes after the backup are discarded by the restore
+	require.NoError(t, s.SaveFeed(&model.Feed{URL: "https://example.com/atom", Title: "After"}))
+	require.NoError(t, s.Close())
+
+	restored, err := Restore(backupPath, dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion(), restored.CurrentVersion)
+
+	s, err = New(dbPath)
+	require.NoError(t, err)
+	defer s.Close()
+	feeds, err := s.GetAllFeeds()
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	assert.Equal(t, "Before", feeds[0].Title)
+
+	_, err = Restore(filepath.Join(dir, "missing.db"), dbPath)
+	assert.Error(t, err)
+
+	_, err = Restore(backupPath, "postgres://localhost/feeds")
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestCheck(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	result, err := s.Check()
+	require.NoError(t, err)
+	assert.True(t, result.OK)
+	assert.Equal(t, []string{"ok"}, result.Integrity)
+
+	// Starred entries kept after their feed is removed are not problems
+	_, err = s.StarEntries([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteFeed(entries[0].FeedID))
+
+	result, err = s.Check()
+	require.NoError(t, err)
+	assert.True(t, result.OK)
+	assert.Equal(t, 1, result.DetachedStarredEntries)
+
+	_, err = s.TagEntries([]int64{entries[0].ID}, "go")
+	require.NoError(t, err)
+	_, err = s.db.Exec("INSERT INTO entry_tags (entry_id, tag_id) SELECT 9999, id FROM tags")
+	require.NoError(t, err)
+
+	result, err = s.Check()
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.Equal(t, []*ForeignKeyViolation{{Table: "entry_tags", Parent: "entries", Rows: 1}}, result.ForeignKeyViolations)
+}
+
+func TestCheck_SearchIndex(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	// Lose one entry's index row, then keep an index row for a deleted entry
+	if testingPostgres() {
+		_, err := s.db.Exec("UPDATE entry_content SET search = NULL WHERE entry_id = ?", entries[0].ID)
+		require.NoError(t, err)
+		_, err = s.db.Exec("DELETE FROM entries WHERE id = ?", entries[1].ID)
+		require.NoError(t, err)
+	} else {
+		_, err := s.db.Exec("DELETE FROM entries_fts WHERE rowid = ?", entries[0].ID)
+		require.NoError(t, err)
+		_, err = s.db.Exec("INSERT INTO entries_fts (rowid, title, content, author) VALUES (9999, 'gone', '', '')")
+		require.NoError(t, err)
+	}
+
+	result, err := s.Check()
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.Equal(t, 1, result.IndexedWithoutEntry)
+	assert.Equal(t, 1, result.EntriesWithoutIndex)
+}
+
+func TestVacuum(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "feed-cli.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	result, err := s.Vacuum()
+	require.NoError(t, err)
+	assert.Positive(t, result.AfterBytes)
+	assert.NoError(t, s.Analyze())
+}
@@ -0,0 +1,478 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaTooNew is returned when a database was created by a newer feed-cli.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of feed-cli supports")
+
+// Migration is a single ordered schema change. The schema version is stored
+// in PRAGMA user_version on SQLite and in the schema_version table on
+// PostgreSQL; each migration runs in its own transaction together with the
+// version bump.
+type Migration struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	SQL         string `json:"-"`
+
+	// Backfill, if set, runs after SQL in the same transaction to fill in
+	// data that needs Go code to compute.
+	Backfill func(tx *txn) error `json:"-"`
+}
+
+// migrations lists every SQLite schema change in order. Never edit or
+// reorder an existing migration; append a new one instead, together with its
+// counterpart in postgresMigrations.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "initial schema: feeds, entries, tags, entry_tags",
+		// IF NOT EXISTS so databases created before migrations existed
+		// (user_version 0, tables already present) upgrade cleanly.
+		SQL: `
+		CREATE TABLE IF NOT EXISTS feeds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT UNIQUE NOT NULL,
+			title TEXT,
+			category TEXT,
+			last_updated INTEGER,
+			etag TEXT,
+			last_modified TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			feed_id INTEGER NOT NULL,
+			guid TEXT NOT NULL,
+			title TEXT,
+			link TEXT,
+			content TEXT,
+			published INTEGER NOT NULL,
+			is_read INTEGER DEFAULT 0,
+			FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE,
+			UNIQUE(feed_id, guid)
+		);
+
+		CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS entry_tags (
+			entry_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (entry_id, tag_id),
+			FOREIGN KEY (entry_id) REFERENCES entries(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_entries_published ON entries(published DESC);
+		CREATE INDEX IF NOT EXISTS idx_entries_is_read ON entries(is_read);
+		CREATE INDEX IF NOT EXISTS idx_entries_feed_id ON entries(feed_id);
+		`,
+	},
+	{
+		Version:     2,
+		Description: "entry author and FTS5 full-text index",
+		// The index holds plain text (strip_html is registered in fts.go), so
+		// it is a regular FTS5 table kept in sync by triggers rather than an
+		// external-content table over entries.
+		SQL: `
+		ALTER TABLE entries ADD COLUMN author TEXT;
+
+		CREATE VIRTUAL TABLE entries_fts USING fts5(
+			title, content, author,
+			tokenize = 'porter unicode61'
+		);
+
+		INSERT INTO entries_fts (rowid, title, content, author)
+			SELECT id, title, strip_html(content), author FROM entries;
+
+		CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts (rowid, title, content, author)
+			VALUES (new.id, new.title, strip_html(new.content), new.author);
+		END;
+
+		CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.id;
+		END;
+
+		CREATE TRIGGER entries_fts_update AFTER UPDATE OF title, content, author ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.id;
+			INSERT INTO entries_fts (rowid, title, content, author)
+			VALUES (new.id, new.title, strip_html(new.content), new.author);
+		END;
+		`,
+	},
+	{
+		Version:     3,
+		Description: "starred entries",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN starred_at INTEGER;
+
+		CREATE INDEX idx_entries_starred_at ON entries(starred_at) WHERE starred_at IS NOT NULL;
+		`,
+	},
+	{
+		Version:     4,
+		Description: "per-feed retention and entry tombstones",
+		SQL: `
+		ALTER TABLE feeds ADD COLUMN retain_count INTEGER;
+		ALTER TABLE feeds ADD COLUMN retain_days INTEGER;
+
+		CREATE TABLE entry_tombstones (
+			feed_id INTEGER NOT NULL,
+			guid TEXT NOT NULL,
+			deleted_at INTEGER NOT NULL,
+			PRIMARY KEY (feed_id, guid)
+		);
+		`,
+	},
+	{
+		Version:     5,
+		Description: "indexes for feed, category and read-state queries",
+		// The rowid (entries.id) is implicitly the last column of every index.
+		// Ascending indexes can be scanned in either direction, so they serve
+		// both "published DESC, id DESC" and "published ASC, id ASC" without a
+		// sort step; the original DESC index could not.
+		SQL: `
+		DROP INDEX IF EXISTS idx_entries_feed_id;
+		DROP INDEX IF EXISTS idx_entries_is_read;
+		DROP INDEX IF EXISTS idx_entries_published;
+
+		CREATE INDEX idx_entries_published ON entries(published);
+		CREATE INDEX idx_entries_feed_published ON entries(feed_id, published);
+		CREATE INDEX idx_entries_read_published ON entries(is_read, published);
+		CREATE INDEX idx_feeds_category ON feeds(category);
+		`,
+	},
+	{
+		Version:     6,
+		Description: "feed fetch health",
+		SQL: `
+		ALTER TABLE feeds ADD COLUMN last_error TEXT;
+		ALTER TABLE feeds ADD COLUMN last_error_at INTEGER;
+		ALTER TABLE feeds ADD COLUMN error_count INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+	{
+		Version:     7,
+		Description: "read timestamps",
+		// Entries read before this migration keep a NULL read_at and do not
+		// appear in the reading history.
+		SQL: `
+		ALTER TABLE entries ADD COLUMN read_at INTEGER;
+
+		CREATE INDEX idx_entries_read_at ON entries(read_at) WHERE read_at IS NOT NULL;
+		`,
+	},
+	{
+		Version:     8,
+		Description: "operations journal",
+		SQL: `
+		CREATE TABLE operations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			command TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			undone_at INTEGER
+		);
+
+		CREATE TABLE operation_changes (
+			op_id INTEGER NOT NULL,
+			entry_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			prev_at INTEGER,
+			new_at INTEGER
+		);
+
+		CREATE INDEX idx_operation_changes_op ON operation_changes(op_id);
+		`,
+	},
+	{
+		Version:     9,
+		Description: "users with their own read, star and tag state",
+		// Entries and feeds stay shared. Read and star state moves to a
+		// sparse per-user table (no row: unread, not starred); existing state,
+		// tags and journal belong to the default user.
+		SQL: `
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+
+		INSERT INTO users (id, name, created_at) VALUES (1, 'default', CAST(strftime('%s', 'now') AS INTEGER));
+
+		CREATE TABLE entry_state (
+			user_id INTEGER NOT NULL,
+			entry_id INTEGER NOT NULL,
+			is_read INTEGER NOT NULL DEFAULT 0,
+			read_at INTEGER,
+			starred_at INTEGER,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		INSERT INTO entry_state (user_id, entry_id, is_read, read_at, starred_at)
+			SELECT 1, id, is_read, read_at, starred_at FROM entries
+			WHERE is_read = 1 OR starred_at IS NOT NULL;
+
+		CREATE INDEX idx_entry_state_entry ON entry_state(entry_id);
+		CREATE INDEX idx_entry_state_read_at ON entry_state(user_id, read_at) WHERE read_at IS NOT NULL;
+		CREATE INDEX idx_entry_state_starred_at ON entry_state(user_id, starred_at) WHERE starred_at IS NOT NULL;
+
+		DROP INDEX idx_entries_read_published;
+		DROP INDEX idx_entries_starred_at;
+		DROP INDEX idx_entries_read_at;
+		ALTER TABLE entries DROP COLUMN is_read;
+		ALTER TABLE entries DROP COLUMN read_at;
+		ALTER TABLE entries DROP COLUMN starred_at;
+
+		CREATE TABLE user_tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			UNIQUE (user_id, name)
+		);
+
+		INSERT INTO user_tags (id, user_id, name) SELECT id, 1, name FROM tags;
+		DROP TABLE tags;
+		ALTER TABLE user_tags RENAME TO tags;
+
+		ALTER TABLE operations ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+		CREATE INDEX idx_operations_user ON operations(user_id, id);
+		`,
+	},
+	{
+		Version:     10,
+		Description: "notes and highlights on entries",
+		SQL: `
+		CREATE TABLE notes (
+			user_id INTEGER NOT NULL,
+			entry_id INTEGER NOT NULL,
+			body TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		CREATE INDEX idx_notes_entry ON notes(entry_id);
+
+		CREATE TABLE highlights (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			entry_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			comment TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);
+
+		CREATE INDEX idx_highlights_user_entry ON highlights(user_id, entry_id);
+		CREATE INDEX idx_highlights_entry ON highlights(entry_id);
+		`,
+	},
+	{
+		Version:     11,
+		Description: "read-later queue",
+		SQL: `
+		CREATE TABLE queue_entries (
+			user_id INTEGER NOT NULL,
+			entry_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			added_at INTEGER NOT NULL,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		CREATE INDEX idx_queue_entries_position ON queue_entries(user_id, position);
+		CREATE INDEX idx_queue_entries_entry ON queue_entries(entry_id);
+		`,
+	},
+	{
+		Version:     12,
+		Description: "entry content in its own table",
+		// Listing entries no longer reads past large HTML bodies. The search
+		// index takes titles and authors from entries and content from
+		// entry_content, so its triggers are split between the two tables.
+		SQL: `
+		CREATE TABLE entry_content (
+			entry_id INTEGER PRIMARY KEY,
+			content TEXT NOT NULL
+		);
+
+		INSERT INTO entry_content (entry_id, content)
+			SELECT id, content FROM entries WHERE content IS NOT NULL AND content <> '';
+
+		DROP TRIGGER entries_fts_insert;
+		DROP TRIGGER entries_fts_update;
+		ALTER TABLE entries DROP COLUMN content;
+
+		CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts (rowid, title, content, author)
+			VALUES (new.id, new.title, '', new.author);
+		END;
+
+		CREATE TRIGGER entries_fts_update AFTER UPDATE OF title, author ON entries BEGIN
+			UPDATE entries_fts SET title = new.title, author = new.author WHERE rowid = new.id;
+		END;
+
+		CREATE TRIGGER entry_content_fts_insert AFTER INSERT ON entry_content BEGIN
+			UPDATE entries_fts SET content = strip_html(new.content) WHERE rowid = new.entry_id;
+		END;
+
+		CREATE TRIGGER entry_content_fts_update AFTER UPDATE OF content ON entry_content BEGIN
+			UPDATE entries_fts SET content = strip_html(new.content) WHERE rowid = new.entry_id;
+		END;
+
+		CREATE TRIGGER entry_content_fts_delete AFTER DELETE ON entry_content BEGIN
+			UPDATE entries_fts SET content = '' WHERE rowid = old.entry_id;
+		END;
+		`,
+	},
+	{
+		Version:     13,
+		Description: "cross-feed duplicate groups",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN canonical_link TEXT NOT NULL DEFAULT '';
+		ALTER TABLE entries ADD COLUMN title_key TEXT NOT NULL DEFAULT '';
+		ALTER TABLE entries ADD COLUMN group_id INTEGER;
+
+		CREATE INDEX idx_entries_canonical_link ON entries(canonical_link);
+		CREATE INDEX idx_entries_title_key ON entries(title_key);
+		CREATE INDEX idx_entries_group_id ON entries(group_id);
+		`,
+		Backfill: backfillDuplicates,
+	},
+	{
+		Version:     14,
+		Description: "original entry links",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN original_link TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		Version:     15,
+		Description: "saved views",
+		SQL: `
+		CREATE TABLE views (
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			filter TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (user_id, name)
+		);
+		`,
+	},
+	{
+		Version:     16,
+		Description: "index entries once, from entry_content",
+		// Every entry now has an entry_content row, empty when it has no
+		// content, and its search row is written once when that row is
+		// inserted instead of by both tables' insert triggers.
+		SQL: `
+		DROP TRIGGER entries_fts_insert;
+		DROP TRIGGER entry_content_fts_insert;
+
+		INSERT INTO entry_content (entry_id, content)
+			SELECT id, '' FROM entries WHERE id NOT IN (SELECT entry_id FROM entry_content);
+
+		CREATE TRIGGER entry_content_fts_insert AFTER INSERT ON entry_content BEGIN
+			INSERT OR REPLACE INTO entries_fts (rowid, title, content, author)
+			SELECT id, title, strip_html(new.content), author FROM entries WHERE id = new.entry_id;
+		END;
+		`,
+	},
+	{
+		Version:     17,
+		Description: "recompute duplicate keys",
+		// Title keys now drop trailing site names and in-word hyphens, and
+		// canonical links share ingest's tracking parameters.
+		Backfill: refreshDuplicateKeys,
+	},
+}
+
+// LatestVersion returns the schema version this build of feed-cli expects.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the database's current schema version.
+func (s *Store) SchemaVersion() (int, error) {
+	version, err := s.db.d.schemaVersion(s.db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// checkVersion returns the schema version, refusing databases written by a newer feed-cli.
+func (s *Store) checkVersion() (int, error) {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	if version > LatestVersion() {
+		return version, fmt.Errorf("%w (database version %d, supported %d)", ErrSchemaTooNew, version, LatestVersion())
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet.
+func (s *Store) PendingMigrations() ([]Migration, error) {
+	version, err := s.checkVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, m := range s.db.d.migrations() {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations in order and returns those applied.
+func (s *Store) Migrate() ([]Migration, error) {
+	pending, err := s.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, m := range pending {
+		if err := s.applyMigration(m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// applyMigration runs one migration and bumps the schema version in the same transaction.
+func (s *Store) applyMigration(m Migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.SQL != "" {
+		if _, err := tx.Exec(m.SQL); err != nil {
+			return err
+		}
+	}
+	if m.Backfill != nil {
+		if err := m.Backfill(tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(s.db.d.setSchemaVersion(m.Version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate_FreshDatabase(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	version, err := s.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion(), version)
+
+	pending, err := s.PendingMigrations()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigrate_LegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// A database created before migrations existed: tables present, user_version 0
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE feeds (id INTEGER PRIMARY KEY AUTOINCREMENT, url TEXT UNIQUE NOT NULL, title TEXT, category TEXT, last_updated INTEGER, etag TEXT, last_modified TEXT);
+		INSERT INTO feeds (url, title, category, etag, last_modified) VALUES ('https://example.com/rss', 'Legacy', '', '', '');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Open does not migrate
+	s, err := Open(path)
+	require.NoError(t, err)
+	pending, err := s.PendingMigrations()
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+	require.NoError(t, s.Close())
+
+	// New migrates and keeps existing data
+	s, err = New(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	version, err := s.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion(), version)
+
+	feeds, err := s.GetAllFeeds()
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	assert.Equal(t, "Legacy", feeds[0].Title)
+}
+
+func TestMigrate_RefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec("PRAGMA user_version = 9999")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = New(path)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+func TestMigrate_FailedMigrationRollsBack(t *testing.T) {
+	skipOnPostgres(t)
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	original := migrations
+	defer func() { migrations = original }()
+	migrations = append(append([]Migration{}, original...), Migration{
+		Version:     LatestVersion() + 1,
+		Description: "broken",
+		SQL:         "CREATE TABLE half_done (id INTEGER); THIS IS NOT SQL;",
+	})
+
+	applied, err := s.Migrate()
+	assert.Error(t, err)
+	assert.Empty(t, applied)
+
+	version, err := s.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, original[len(original)-1].Version, version, "Version should not advance")
+
+	var count int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&count))
+	assert.Zero(t, count, "Partial changes should be rolled back")
+}
+
+func TestMigrate_ContentRowsForEveryEntry(t *testing.T) {
+	skipOnPostgres(t)
+	path := filepath.Join(t.TempDir(), "v15.db")
+
+	// Before version 16 entries without content had no entry_content row
+	original := migrations
+	migrations = original[:15]
+	s, err := New(path)
+	migrations = original
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.db.Exec("INSERT INTO feeds (url) VALUES ('https://example.com/rss')")
+	require.NoError(t, err)
+	_, err = s.db.Exec("INSERT INTO entries (feed_id, guid, title, link, published) VALUES (1, 'a', 'Notes on caching', '', 0)")
+	require.NoError(t, err)
+
+	_, err = s.Migrate()
+	require.NoError(t, err)
+
+	var missing int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM entries WHERE id NOT IN (SELECT entry_id FROM entry_content)").Scan(&missing))
+	assert.Zero(t, missing)
+
+	entry, err := s.GetEntry(1)
+	require.NoError(t, err)
+	entry.Content = "<p>Invalidation is hard</p>"
+	require.NoError(t, s.SaveEntry(entry))
+	results, err := s.Search("caching invalidation", QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	check, err := s.Check()
+	require.NoError(t, err)
+	assert.True(t, check.OK)
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// NoteOptions filters the entries the current user has annotated.
+type NoteOptions struct {
+	Search  string  // Case-insensitive text in the note, a highlight or its comment
+	FeedIDs []int64 // Entries from any of these feeds
+	Limit   int     // 0 = no limit
+}
+
+// SetNote saves the current user's Markdown note on an entry, replacing any
+// earlier note.
+func (s *Store) SetNote(entryID int64, body string) (*model.Note, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, errors.New("note is empty")
+	}
+	if err := s.checkEntry(entryID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	_, err := s.db.Exec(`
+		INSERT INTO notes (user_id, entry_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, entry_id) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at`,
+		s.userID, entryID, body, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	note := &model.Note{Body: body}
+	var createdAt, updatedAt int64
+	err = s.db.QueryRow("SELECT created_at, updated_at FROM notes WHERE user_id = ? AND entry_id = ?", s.userID, entryID).
+		Scan(&createdAt, &updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	note.CreatedAt = unixToTime(createdAt)
+	note.UpdatedAt = unixToTime(updatedAt)
+	return note, nil
+}
+
+// DeleteNote removes the current user's note on an entry.
+func (s *Store) DeleteNote(entryID int64) error {
+	result, err := s.db.Exec("DELETE FROM notes WHERE user_id = ? AND entry_id = ?", s.userID, entryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return errors.New("note not found")
+	}
+	return nil
+}
+
+// AddHighlight records a quoted span of an entry for the current user.
+func (s *Store) AddHighlight(entryID int64, text, comment string) (*model.Highlight, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("highlight text is required")
+	}
+	if err := s.checkEntry(entryID); err != nil {
+		return nil, err
+	}
+
+	h := &model.Highlight{
+		EntryID:   entryID,
+		Text:      text,
+		Comment:   strings.TrimSpace(comment),
+		CreatedAt: unixToTime(time.Now().Unix()),
+	}
+	err := s.db.QueryRow(
+		"INSERT INTO highlights (user_id, entry_id, text, comment, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id",
+		s.userID, h.EntryID, h.Text, h.Comment, h.CreatedAt.Unix(),
+	).Scan(&h.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save highlight: %w", err)
+	}
+	return h, nil
+}
+
+// DeleteHighlight removes one of the current user's highlights.
+func (s *Store) DeleteHighlight(id int64) error {
+	result, err := s.db.Exec("DELETE FROM highlights WHERE user_id = ? AND id = ?", s.userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete highlight: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return errors.New("highlight not found")
+	}
+	return nil
+}
+
+// GetNotes returns the entries the current user has annotated, with their
+// note and highlights, most recently annotated first.
+func (s *Store) GetNotes(opts NoteOptions) ([]*model.Entry, error) {
+	var noteWhere, highlightWhere string
+	args := []interface{}{s.userID}
+	if opts.Search != "" {
+		pattern := likePattern(opts.Search)
+		noteWhere = ` AND LOWER(body) LIKE ? ESCAPE '\'`
+		args = append(args, pattern)
+		highlightWhere = ` AND (LOWER(text) LIKE ? ESCAPE '\' OR LOWER(comment) LIKE ? ESCAPE '\')`
+		args = append(args, s.userID, pattern, pattern)
+	} else {
+		args = append(args, s.userID)
+	}
+
+	query := "SELECT " + entryColumns + `
+		FROM (
+			SELECT entry_id, MAX(at) AS annotated_at FROM (
+				SELECT entry_id, updated_at AS at FROM notes WHERE user_id = ?` + noteWhere + `
+				UNION ALL
+				SELECT entry_id, created_at FROM highlights WHERE user_id = ?` + highlightWhere + `
+			) AS a GROUP BY entry_id
+		) AS annotated
+		JOIN entries ON entries.id = annotated.entry_id` + s.stateJoin()
+	if len(opts.FeedIDs) > 0 {
+		query += " WHERE entries.feed_id IN (" + placeholders(len(opts.FeedIDs)) + ")"
+		for _, id := range opts.FeedIDs {
+			args = append(args, id)
+		}
+	}
+	query += " ORDER BY annotated.annotated_at DESC, entries.id DESC"
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadTags(entries); err != nil {
+		return nil, err
+	}
+	if err := s.loadAnnotations(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// checkEntry returns an error if the entry does not exist.
+func (s *Store) checkEntry(id int64) error {
+	var found int
+	err := s.db.QueryRow("SELECT 1 FROM entries WHERE id = ?", id).Scan(&found)
+	if err == sql.ErrNoRows {
+		return errors.New("entry not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get entry: %w", err)
+	}
+	return nil
+}
+
+// likePattern turns text into a LIKE pattern matching it anywhere, compared
+// against LOWER(column).
+func likePattern(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(text))
+	return "%" + escaped + "%"
+}
+
+// loadAnnotations fills in the current user's note and highlights for each entry.
+func (s *Store) loadAnnotations(entries []*model.Entry) error {
+	for start := 0; start < len(entries); start += loadTagsBatch {
+		end := start + loadTagsBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := s.loadAnnotationsChunk(entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) loadAnnotationsChunk(entries []*model.Entry) error {
+	byID := make(map[int64]*model.Entry, len(entries))
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, s.userID)
+	for _, e := range entries {
+		byID[e.ID] = e
+		args = append(args, e.ID)
+	}
+	where := "user_id = ? AND entry_id IN (" + placeholders(len(entries)) + ")"
+
+	if err := s.loadNotes(byID, where, args); err != nil {
+		return err
+	}
+	return s.loadHighlights(byID, where, args)
+}
+
+func (s *Store) loadNotes(byID map[int64]*model.Entry, where string, args []interface{}) error {
+	rows, err := s.db.Query("SELECT entry_id, body, created_at, updated_at FROM notes WHERE "+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID, createdAt, updatedAt int64
+		note := &model.Note{}
+		if err := rows.Scan(&entryID, &note.Body, &createdAt, &updatedAt); err != nil {
+			return fmt.Errorf("failed to scan note: %w", err)
+		}
+		note.CreatedAt = unixToTime(createdAt)
+		note.UpdatedAt = unixToTime(updatedAt)
+		if e, ok := byID[entryID]; ok {
+			e.Note = note
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) loadHighlights(byID map[int64]*model.Entry, where string, args []interface{}) error {
+	rows, err := s.db.Query("SELECT id, entry_id, text, comment, created_at FROM highlights WHERE "+where+" ORDER BY id", args...)
+	if err != nil {
+		return fmt.Errorf("failed to query highlights: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h := &model.Highlight{}
+		var createdAt int64
+		if err := rows.Scan(&h.ID, &h.EntryID, &h.Text, &h.Comment, &createdAt); err != nil {
+			return fmt.Errorf("failed to scan highlight: %w", err)
+		}
+		h.CreatedAt = unixToTime(createdAt)
+		if e, ok := byID[h.EntryID]; ok {
+			e.Highlights = append(e.Highlights, h)
+		}
+	}
+	return rows.Err()
+}
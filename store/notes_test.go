@@ -0,0 +1,138 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotes_SetAndDelete(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.SetNote(entries[0].ID, "  ")
+	assert.Error(t, err)
+	_, err = s.SetNote(9999, "Missing")
+	assert.Error(t, err)
+
+	note, err := s.SetNote(entries[0].ID, "First *draft*\n")
+	require.NoError(t, err)
+	assert.Equal(t, "First *draft*", note.Body)
+
+	// Saving again replaces the note and keeps its creation time
+	updated, err := s.SetNote(entries[0].ID, "Second draft")
+	require.NoError(t, err)
+	assert.Equal(t, note.CreatedAt, updated.CreatedAt)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, entry.Note)
+	assert.Equal(t, "Second draft", entry.Note.Body)
+
+	require.NoError(t, s.DeleteNote(entries[0].ID))
+	assert.Error(t, s.DeleteNote(entries[0].ID))
+
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, entry.Note)
+}
+
+func TestNotes_Highlights(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.AddHighlight(entries[0].ID, "", "")
+	assert.Error(t, err)
+	_, err = s.AddHighlight(9999, "Quote", "")
+	assert.Error(t, err)
+
+	first, err := s.AddHighlight(entries[0].ID, "A quote", " Why it matters ")
+	require.NoError(t, err)
+	assert.Equal(t, "Why it matters", first.Comment)
+	_, err = s.AddHighlight(entries[0].ID, "Another quote", "")
+	require.NoError(t, err)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	require.Len(t, entry.Highlights, 2)
+	assert.Equal(t, "A quote", entry.Highlights[0].Text)
+	assert.Equal(t, first.ID, entry.Highlights[0].ID)
+
+	require.NoError(t, s.DeleteHighlight(first.ID))
+	assert.Error(t, s.DeleteHighlight(first.ID))
+
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, entry.Highlights, 1)
+}
+
+func TestNotes_ListAndSearch(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.SetNote(entries[2].ID, "About 100% of Go")
+	require.NoError(t, err)
+	_, err = s.AddHighlight(entries[0].ID, "Rust quote", "Compare with GO")
+	require.NoError(t, err)
+
+	notes, err := s.GetNotes(NoteOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "c"}, entryGUIDs(notes))
+
+	notes, err = s.GetNotes(NoteOptions{Search: "go"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "c"}, entryGUIDs(notes), "Searches notes and highlight comments")
+
+	notes, err = s.GetNotes(NoteOptions{Search: "rust"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, entryGUIDs(notes))
+	assert.Len(t, notes[0].Highlights, 1)
+
+	notes, err = s.GetNotes(NoteOptions{Search: "100%"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, entryGUIDs(notes))
+
+	notes, err = s.GetNotes(NoteOptions{Search: "0_"})
+	require.NoError(t, err)
+	assert.Empty(t, notes, "LIKE wildcards are matched literally")
+
+	notes, err = s.GetNotes(NoteOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, notes, 1)
+
+	// Notes belong to the user who wrote them
+	addUsers(t, s, "alice")
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	notes, err = s.GetNotes(NoteOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+	entry, err := s.GetEntry(entries[2].ID)
+	require.NoError(t, err)
+	assert.Nil(t, entry.Note)
+}
+
+func TestNotes_AnnotatedEntriesAreKept(t *testing.T) {
+	s, feed, entries := newPruneStore(t)
+	defer s.Close()
+
+	_, err := s.SetNote(entries[3].ID, "Keep me")
+	require.NoError(t, err)
+	_, err = s.AddHighlight(entries[4].ID, "And me", "")
+	require.NoError(t, err)
+
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+
+	require.NoError(t, s.DeleteFeed(feed.ID))
+	notes, err := s.GetNotes(NoteOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"e3", "e4"}, entryGUIDs(notes))
+
+	check, err := s.Check()
+	require.NoError(t, err)
+	assert.True(t, check.OK)
+	assert.Equal(t, 2, check.DetachedStarredEntries)
+}
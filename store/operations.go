@@ -0,0 +1,312 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Journaled entry state changes.
+const (
+	ActionRead   = "read"
+	ActionUnread = "unread"
+	ActionStar   = "star"
+	ActionUnstar = "unstar"
+)
+
+// maxOperations is how many operations the journal keeps per user; older
+// ones are dropped when a new one is recorded and can no longer be undone.
+const maxOperations = 100
+
+// ErrNothingToUndo is returned by Undo when every journaled operation has
+// already been undone.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Operation is a group of journaled read/star changes, normally one command.
+type Operation struct {
+	ID        int64          `json:"id"`
+	Command   string         `json:"command"`
+	CreatedAt time.Time      `json:"created_at"`
+	UndoneAt  *time.Time     `json:"undone_at,omitempty"`
+	Changes   map[string]int `json:"changes"` // Entries changed per action
+}
+
+// UndoResult describes an undone operation.
+type UndoResult struct {
+	Operation *Operation `json:"operation"`
+	Reverted  int        `json:"reverted"`
+	Skipped   int        `json:"skipped"` // Entries changed again since, or deleted
+}
+
+// BeginOperation starts a new journal operation labelled command. Read and
+// star changes made through s from now on are grouped under it. Without it,
+// changes are still journaled, all under one unlabelled operation.
+func (s *Store) BeginOperation(command string) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	s.opCommand = command
+	s.opID = 0
+}
+
+// OperationID returns the ID of the current operation, or 0 if nothing has
+// changed since BeginOperation.
+func (s *Store) OperationID() int64 {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	return s.opID
+}
+
+// changeEntries applies set (with newAt as its only parameter, unless nil)
+// to the current user's state of the entries matching where, journaling each
+// entry's previous value of column under action. where may refer to the
+// state as st (see stateJoin). It returns the number of entries changed.
+func (s *Store) changeEntries(action, column string, newAt interface{}, set, where string, args []interface{}) (int, error) {
+	return s.journaled(func(tx *txn) (int, int64, error) {
+		return s.changeEntriesTx(tx, action, column, newAt, set, where, args)
+	})
+}
+
+// journaled runs change in a transaction holding s.opMu. change returns how
+// many entries it changed and the operation it journaled them under, which
+// becomes the current operation once the transaction commits.
+func (s *Store) journaled(change func(tx *txn) (int, int64, error)) (int, error) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	n, opID, err := change(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit changes: %w", err)
+	}
+	s.opID = opID
+	return n, nil
+}
+
+// changeEntriesTx is changeEntries within tx, returning the operation the
+// changes were journaled under. An operation is only created when some
+// entry changes. s.opMu must be held.
+func (s *Store) changeEntriesTx(tx *txn, action, column string, newAt interface{}, set, where string, args []interface{}) (int, int64, error) {
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM entries"+s.stateJoin()+" WHERE "+where, args...).Scan(&n); err != nil {
+		return 0, 0, fmt.Errorf("failed to count entries: %w", err)
+	}
+	if n == 0 {
+		return 0, s.opID, nil
+	}
+
+	opID := s.opID
+	if opID == 0 {
+		err := tx.QueryRow("INSERT INTO operations (user_id, command, created_at) VALUES (?, ?, ?) RETURNING id", s.userID, s.opCommand, time.Now().Unix()).Scan(&opID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to record operation: %w", err)
+		}
+		if err := s.trimJournal(tx); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	_, err := tx.Exec(
+		"INSERT INTO operation_changes (op_id, entry_id, action, prev_at, new_at) SELECT CAST(? AS BIGINT), entries.id, CAST(? AS TEXT), st."+column+", CAST(? AS BIGINT) FROM entries"+s.stateJoin()+" WHERE "+where,
+		append([]interface{}{opID, action, newAt}, args...)...,
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to journal changes: %w", err)
+	}
+
+	// Entries the user never touched have no state row yet; a default row
+	// matches where exactly like the missing one did
+	_, err = tx.Exec(
+		"INSERT INTO entry_state (user_id, entry_id) SELECT CAST(? AS BIGINT), entries.id FROM entries"+s.stateJoin()+" WHERE st.entry_id IS NULL AND "+where,
+		append([]interface{}{s.userID}, args...)...,
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create entry state: %w", err)
+	}
+
+	setArgs := []interface{}{}
+	if newAt != nil {
+		setArgs = append(setArgs, newAt)
+	}
+	setArgs = append(setArgs, s.userID)
+	_, err = tx.Exec(
+		"UPDATE entry_state SET "+set+" WHERE user_id = ? AND entry_id IN (SELECT entries.id FROM entries"+s.stateJoin()+" WHERE "+where+")",
+		append(setArgs, args...)...,
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to update entries: %w", err)
+	}
+	return n, opID, nil
+}
+
+// trimJournal drops the current user's operations beyond the newest
+// maxOperations, with their changes.
+func (s *Store) trimJournal(tx *txn) error {
+	var cutoff int64
+	err := tx.QueryRow(
+		"SELECT id FROM operations WHERE user_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?",
+		s.userID, maxOperations-1,
+	).Scan(&cutoff)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to trim journal: %w", err)
+	}
+
+	for _, table := range []string{"operation_changes WHERE op_id IN (SELECT id FROM operations", "operations WHERE id IN (SELECT id FROM operations"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ? AND id < ?)", s.userID, cutoff); err != nil {
+			return fmt.Errorf("failed to trim journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetOperations returns the current user's most recent journal operations,
+// newest first. A limit of 0 returns all of them.
+func (s *Store) GetOperations(limit int) ([]*Operation, error) {
+	if limit <= 0 {
+		return s.queryOperations("SELECT id FROM operations WHERE user_id = ?", s.userID)
+	}
+	return s.queryOperations("SELECT id FROM operations WHERE user_id = ? ORDER BY id DESC LIMIT ?", s.userID, limit)
+}
+
+// getOperation returns the current user's operation with the given ID.
+func (s *Store) getOperation(id int64) (*Operation, error) {
+	ops, err := s.queryOperations("SELECT id FROM operations WHERE user_id = ? AND id = ?", s.userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("operation %d not found", id)
+	}
+	return ops[0], nil
+}
+
+// queryOperations loads the operations whose IDs ids selects, with their
+// change counts, newest first.
+func (s *Store) queryOperations(ids string, args ...interface{}) ([]*Operation, error) {
+	rows, err := s.db.Query(`
+		SELECT o.id, o.command, o.created_at, o.undone_at, c.action, COUNT(c.entry_id)
+		FROM operations o LEFT JOIN operation_changes c ON c.op_id = o.id
+		WHERE o.id IN (`+ids+`)
+		GROUP BY o.id, c.action
+		ORDER BY o.id DESC, c.action`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %w", err)
+	}
+	defer rows.Close()
+
+	ops := []*Operation{}
+	var op *Operation
+	for rows.Next() {
+		var id, createdAt int64
+		var command string
+		var undoneAt sql.NullInt64
+		var action sql.NullString
+		var count int
+		if err := rows.Scan(&id, &command, &createdAt, &undoneAt, &action, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+
+		if op == nil || op.ID != id {
+			op = &Operation{
+				ID:        id,
+				Command:   command,
+				CreatedAt: unixToTime(createdAt),
+				UndoneAt:  nullUnixToTime(undoneAt),
+				Changes:   map[string]int{},
+			}
+			ops = append(ops, op)
+		}
+		if action.Valid {
+			op.Changes[action.String] = count
+		}
+	}
+
+	return ops, rows.Err()
+}
+
+// Undo reverts the current user's operation with the given ID, or their most
+// recent one not yet undone if id is 0. Entries that were changed again since, or deleted,
+// are left alone and counted as skipped.
+func (s *Store) Undo(id int64) (*UndoResult, error) {
+	if id == 0 {
+		err := s.db.QueryRow("SELECT id FROM operations WHERE user_id = ? AND undone_at IS NULL ORDER BY id DESC LIMIT 1", s.userID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, ErrNothingToUndo
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find last operation: %w", err)
+		}
+	}
+
+	op, err := s.getOperation(id)
+	if err != nil {
+		return nil, err
+	}
+	if op.UndoneAt != nil {
+		return nil, fmt.Errorf("operation %d was already undone", id)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Only entries still in the state the operation left them in are reverted
+	reverts := []string{`
+		UPDATE entry_state SET is_read = CASE WHEN c.action = 'unread' THEN 1 ELSE 0 END, read_at = c.prev_at
+		FROM operation_changes AS c
+		WHERE c.op_id = ? AND c.action IN ('read', 'unread') AND c.entry_id = entry_state.entry_id
+			AND entry_state.user_id = ?
+			AND entry_state.is_read = CASE WHEN c.action = 'read' THEN 1 ELSE 0 END
+			AND entry_state.read_at IS NOT DISTINCT FROM c.new_at`, `
+		UPDATE entry_state SET starred_at = c.prev_at
+		FROM operation_changes AS c
+		WHERE c.op_id = ? AND c.action IN ('star', 'unstar') AND c.entry_id = entry_state.entry_id
+			AND entry_state.user_id = ?
+			AND entry_state.starred_at IS NOT DISTINCT FROM c.new_at`,
+	}
+
+	result := &UndoResult{}
+	for _, revert := range reverts {
+		res, err := tx.Exec(revert, id, s.userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revert operation: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		result.Reverted += int(n)
+	}
+
+	now := time.Now().Unix()
+	if _, err := tx.Exec("UPDATE operations SET undone_at = ? WHERE id = ?", now, id); err != nil {
+		return nil, fmt.Errorf("failed to mark operation undone: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit undo: %w", err)
+	}
+
+	undoneAt := unixToTime(now)
+	op.UndoneAt = &undoneAt
+	result.Operation = op
+	for _, count := range op.Changes {
+		result.Skipped += count
+	}
+	result.Skipped -= result.Reverted
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndo_MarkRead(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	before, err := s.GetEntries(QueryOptions{Sort: SortID})
+	require.NoError(t, err)
+
+	s.BeginOperation("mark-all-read")
+	marked, err := s.MarkReadWhere(QueryOptions{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+	opID := s.OperationID()
+	assert.NotZero(t, opID)
+
+	result, err := s.Undo(0)
+	require.NoError(t, err)
+	assert.Equal(t, opID, result.Operation.ID)
+	assert.Equal(t, "mark-all-read", result.Operation.Command)
+	assert.Equal(t, 2, result.Reverted)
+	assert.Zero(t, result.Skipped)
+
+	after, err := s.GetEntries(QueryOptions{Sort: SortID})
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	_, err = s.Undo(opID)
+	assert.Error(t, err, "An operation can only be undone once")
+	_, err = s.Undo(0)
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+}
+
+func TestUndo_GroupsChangesPerOperation(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	// Nothing changed: no operation is recorded
+	s.BeginOperation("unstar")
+	_, err := s.StarEntries([]int64{entries[0].ID}, false)
+	require.NoError(t, err)
+	assert.Zero(t, s.OperationID())
+
+	s.BeginOperation("triage")
+	_, err = s.StarEntries([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+	_, err = s.MarkEntriesRead([]int64{entries[0].ID, entries[1].ID}, true)
+	require.NoError(t, err)
+	triage := s.OperationID()
+
+	// A later change to entries[1] is not clobbered by undoing triage
+	s.BeginOperation("mark-unread")
+	_, err = s.MarkEntriesRead([]int64{entries[1].ID}, false)
+	require.NoError(t, err)
+
+	ops, err := s.GetOperations(0)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	assert.Equal(t, map[string]int{ActionRead: 2, ActionStar: 1}, ops[1].Changes)
+
+	result, err := s.Undo(triage)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Reverted)
+	assert.Equal(t, 1, result.Skipped)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.False(t, entry.IsRead)
+	assert.False(t, entry.IsStarred())
+
+	// The last operation not yet undone is still available
+	result, err = s.Undo(0)
+	require.NoError(t, err)
+	assert.Equal(t, "mark-unread", result.Operation.Command)
+	assert.Equal(t, 1, result.Reverted)
+
+	entry, err = s.GetEntry(entries[1].ID)
+	require.NoError(t, err)
+	assert.True(t, entry.IsRead)
+	assert.NotNil(t, entry.ReadAt)
+}
+
+func TestOperations_Trimmed(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	for i := 0; i < maxOperations+5; i++ {
+		s.BeginOperation("toggle")
+		_, err := s.MarkEntriesRead([]int64{entries[0].ID}, i%2 == 0)
+		require.NoError(t, err)
+	}
+
+	ops, err := s.GetOperations(0)
+	require.NoError(t, err)
+	assert.Len(t, ops, maxOperations)
+
+	var changes int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM operation_changes").Scan(&changes))
+	assert.Equal(t, maxOperations, changes)
+}
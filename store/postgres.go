@@ -0,0 +1,507 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// postgresDialect runs the store on a shared PostgreSQL database, selected
+// with a postgres:// URL.
+type postgresDialect struct{}
+
+func (postgresDialect) name() string       { return "postgres" }
+func (postgresDialect) driverName() string { return "postgres" }
+
+func (postgresDialect) dataSource(url string) string { return url }
+
+func (postgresDialect) rebind(query string) string { return numberPlaceholders(query) }
+
+func (postgresDialect) migrations() []Migration { return postgresMigrations }
+
+func (postgresDialect) schemaVersion(c *conn) (int, error) {
+	// The table is created by the first migration; a fresh database is version 0
+	var exists bool
+	if err := c.QueryRow("SELECT to_regclass('schema_version') IS NOT NULL").Scan(&exists); err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	err := c.QueryRow("SELECT version FROM schema_version").Scan(&version)
+	return version, err
+}
+
+func (postgresDialect) setSchemaVersion(version int) string {
+	return "UPDATE schema_version SET version = " + strconv.Itoa(version)
+}
+
+func (postgresDialect) searchQuery(stateJoin string) string {
+	return "SELECT " + entryColumns + `,
+		ts_headline('english', strip_html(ec.content), q.query,
+			'StartSel=' || CAST(? AS TEXT) || ', StopSel=' || CAST(? AS TEXT) || ', MaxWords=16, MinWords=8'),
+		-ts_rank(ec.search, q.query) AS rank
+		FROM entries CROSS JOIN websearch_to_tsquery('english', ?) AS q(query)
+		JOIN entry_content AS ec ON ec.entry_id = entries.id` + stateJoin + `
+		WHERE ec.search @@ q.query`
+}
+
+func (postgresDialect) sizeBytes(c *conn) (int64, int64, error) {
+	// Dead tuples are not tracked as a free list; report none reclaimable
+	var size int64
+	err := c.QueryRow("SELECT pg_database_size(current_database())").Scan(&size)
+	return size, 0, err
+}
+
+// integrityCheck reports indexes left invalid by an interrupted build and,
+// when the amcheck extension is installed, B-tree indexes whose structure is
+// corrupt. Like SQLite's integrity_check, a clean database reports "ok".
+func (postgresDialect) integrityCheck(c *conn) ([]string, error) {
+	rows, err := c.Query(`
+		SELECT cl.relname FROM pg_index i JOIN pg_class cl ON cl.oid = i.indexrelid
+		WHERE cl.relnamespace = CAST(current_schema() AS regnamespace) AND NOT (i.indisvalid AND i.indisready)
+		ORDER BY cl.relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []string{}
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			return nil, err
+		}
+		messages = append(messages, "index "+index+" is invalid; rebuild it with REINDEX")
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The extension may live in any schema
+	var amcheck string
+	err = c.QueryRow(`
+		SELECT n.nspname FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'amcheck'`).Scan(&amcheck)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if amcheck != "" {
+		_, err := c.Exec(`
+			SELECT ` + pq.QuoteIdentifier(amcheck) + `.bt_index_check(i.indexrelid) FROM pg_index i
+			JOIN pg_class cl ON cl.oid = i.indexrelid JOIN pg_am am ON am.oid = cl.relam
+			WHERE cl.relnamespace = CAST(current_schema() AS regnamespace) AND am.amname = 'btree'
+				AND i.indisvalid AND i.indisready`)
+		// Corruption is reported in the XX (internal error) class; anything
+		// else is a failure to run the check
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Class() == "XX" {
+			messages = append(messages, pqErr.Message)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(messages) == 0 {
+		messages = append(messages, "ok")
+	}
+	return messages, nil
+}
+
+// searchIndexCheck counts search vectors kept for entries that are gone, and
+// entries whose content row is missing or whose vector no longer matches
+// their title, author and content.
+func (postgresDialect) searchIndexCheck(c *conn) (int, int, error) {
+	var orphaned, missing int
+	err := c.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM entry_content WHERE entry_id NOT IN (SELECT id FROM entries)),
+			(SELECT COUNT(*) FROM entries LEFT JOIN entry_content AS ec ON ec.entry_id = entries.id
+				WHERE ec.search IS DISTINCT FROM entry_search(entries.title, entries.author, ec.content))`,
+	).Scan(&orphaned, &missing)
+	return orphaned, missing, err
+}
+
+// postgresMigrations mirrors migrations version for version. Times are
+// BIGINT Unix timestamps and booleans INTEGER, as on SQLite, so queries are
+// shared. Like the SQLite schema, references between tables are not enforced:
+// starred entries outlive their feed.
+var postgresMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "initial schema: feeds, entries, tags, entry_tags",
+		SQL: `
+		CREATE TABLE schema_version (version INTEGER NOT NULL);
+		INSERT INTO schema_version (version) VALUES (0);
+
+		CREATE TABLE feeds (
+			id BIGSERIAL PRIMARY KEY,
+			url TEXT UNIQUE NOT NULL,
+			title TEXT,
+			category TEXT,
+			last_updated BIGINT,
+			etag TEXT,
+			last_modified TEXT
+		);
+
+		CREATE TABLE entries (
+			id BIGSERIAL PRIMARY KEY,
+			feed_id BIGINT NOT NULL,
+			guid TEXT NOT NULL,
+			title TEXT,
+			link TEXT,
+			content TEXT,
+			published BIGINT NOT NULL,
+			is_read INTEGER DEFAULT 0,
+			UNIQUE (feed_id, guid)
+		);
+
+		CREATE TABLE tags (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL
+		);
+
+		CREATE TABLE entry_tags (
+			entry_id BIGINT NOT NULL,
+			tag_id BIGINT NOT NULL,
+			PRIMARY KEY (entry_id, tag_id)
+		);
+
+		CREATE INDEX idx_entries_published ON entries(published DESC);
+		CREATE INDEX idx_entries_is_read ON entries(is_read);
+		CREATE INDEX idx_entries_feed_id ON entries(feed_id);
+		`,
+	},
+	{
+		Version:     2,
+		Description: "entry author and full-text search vector",
+		// A generated tsvector replaces FTS5; weights A, B and D rank titles
+		// above authors above content, close to the bm25 weights on SQLite.
+		// strip_html approximates the Go StripHTML used on SQLite.
+		SQL: `
+		ALTER TABLE entries ADD COLUMN author TEXT;
+
+		CREATE FUNCTION strip_html(html TEXT) RETURNS TEXT
+		LANGUAGE SQL IMMUTABLE AS $$
+			SELECT regexp_replace(regexp_replace(regexp_replace(COALESCE(html, ''),
+				'<(script|style)[^>]*>[^<]*</(script|style)>', ' ', 'gi'),
+				'<[^>]*>', ' ', 'g'),
+				'&(#[0-9]+|[a-z]+);', ' ', 'gi')
+		$$;
+
+		ALTER TABLE entries ADD COLUMN search tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+			setweight(to_tsvector('english', COALESCE(author, '')), 'B') ||
+			setweight(to_tsvector('english', strip_html(content)), 'D')
+		) STORED;
+
+		CREATE INDEX idx_entries_search ON entries USING GIN (search);
+		`,
+	},
+	{
+		Version:     3,
+		Description: "starred entries",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN starred_at BIGINT;
+
+		CREATE INDEX idx_entries_starred_at ON entries(starred_at) WHERE starred_at IS NOT NULL;
+		`,
+	},
+	{
+		Version:     4,
+		Description: "per-feed retention and entry tombstones",
+		SQL: `
+		ALTER TABLE feeds ADD COLUMN retain_count INTEGER;
+		ALTER TABLE feeds ADD COLUMN retain_days INTEGER;
+
+		CREATE TABLE entry_tombstones (
+			feed_id BIGINT NOT NULL,
+			guid TEXT NOT NULL,
+			deleted_at BIGINT NOT NULL,
+			PRIMARY KEY (feed_id, guid)
+		);
+		`,
+	},
+	{
+		Version:     5,
+		Description: "indexes for feed, category and read-state queries",
+		SQL: `
+		DROP INDEX IF EXISTS idx_entries_feed_id;
+		DROP INDEX IF EXISTS idx_entries_is_read;
+		DROP INDEX IF EXISTS idx_entries_published;
+
+		CREATE INDEX idx_entries_published ON entries(published, id);
+		CREATE INDEX idx_entries_feed_published ON entries(feed_id, published, id);
+		CREATE INDEX idx_entries_read_published ON entries(is_read, published, id);
+		CREATE INDEX idx_feeds_category ON feeds(category);
+		`,
+	},
+	{
+		Version:     6,
+		Description: "feed fetch health",
+		SQL: `
+		ALTER TABLE feeds ADD COLUMN last_error TEXT;
+		ALTER TABLE feeds ADD COLUMN last_error_at BIGINT;
+		ALTER TABLE feeds ADD COLUMN error_count INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+	{
+		Version:     7,
+		Description: "read timestamps",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN read_at BIGINT;
+
+		CREATE INDEX idx_entries_read_at ON entries(read_at) WHERE read_at IS NOT NULL;
+		`,
+	},
+	{
+		Version:     8,
+		Description: "operations journal",
+		SQL: `
+		CREATE TABLE operations (
+			id BIGSERIAL PRIMARY KEY,
+			command TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			undone_at BIGINT
+		);
+
+		CREATE TABLE operation_changes (
+			op_id BIGINT NOT NULL,
+			entry_id BIGINT NOT NULL,
+			action TEXT NOT NULL,
+			prev_at BIGINT,
+			new_at BIGINT
+		);
+
+		CREATE INDEX idx_operation_changes_op ON operation_changes(op_id);
+		`,
+	},
+	{
+		Version:     9,
+		Description: "users with their own read, star and tag state",
+		SQL: `
+		CREATE TABLE users (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+
+		INSERT INTO users (id, name, created_at) VALUES (1, 'default', CAST(EXTRACT(EPOCH FROM now()) AS BIGINT));
+		SELECT setval(pg_get_serial_sequence('users', 'id'), 1);
+
+		CREATE TABLE entry_state (
+			user_id BIGINT NOT NULL,
+			entry_id BIGINT NOT NULL,
+			is_read INTEGER NOT NULL DEFAULT 0,
+			read_at BIGINT,
+			starred_at BIGINT,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		INSERT INTO entry_state (user_id, entry_id, is_read, read_at, starred_at)
+			SELECT 1, id, is_read, read_at, starred_at FROM entries
+			WHERE is_read = 1 OR starred_at IS NOT NULL;
+
+		CREATE INDEX idx_entry_state_entry ON entry_state(entry_id);
+		CREATE INDEX idx_entry_state_read_at ON entry_state(user_id, read_at) WHERE read_at IS NOT NULL;
+		CREATE INDEX idx_entry_state_starred_at ON entry_state(user_id, starred_at) WHERE starred_at IS NOT NULL;
+
+		ALTER TABLE entries DROP COLUMN is_read;
+		ALTER TABLE entries DROP COLUMN read_at;
+		ALTER TABLE entries DROP COLUMN starred_at;
+
+		ALTER TABLE tags ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1;
+		ALTER TABLE tags ALTER COLUMN user_id DROP DEFAULT;
+		ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+		ALTER TABLE tags ADD UNIQUE (user_id, name);
+
+		ALTER TABLE operations ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1;
+		CREATE INDEX idx_operations_user ON operations(user_id, id);
+		`,
+	},
+	{
+		Version:     10,
+		Description: "notes and highlights on entries",
+		SQL: `
+		CREATE TABLE notes (
+			user_id BIGINT NOT NULL,
+			entry_id BIGINT NOT NULL,
+			body TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		CREATE INDEX idx_notes_entry ON notes(entry_id);
+
+		CREATE TABLE highlights (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			entry_id BIGINT NOT NULL,
+			text TEXT NOT NULL,
+			comment TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL
+		);
+
+		CREATE INDEX idx_highlights_user_entry ON highlights(user_id, entry_id);
+		CREATE INDEX idx_highlights_entry ON highlights(entry_id);
+		`,
+	},
+	{
+		Version:     11,
+		Description: "read-later queue",
+		SQL: `
+		CREATE TABLE queue_entries (
+			user_id BIGINT NOT NULL,
+			entry_id BIGINT NOT NULL,
+			position INTEGER NOT NULL,
+			added_at BIGINT NOT NULL,
+			PRIMARY KEY (user_id, entry_id)
+		);
+
+		CREATE INDEX idx_queue_entries_position ON queue_entries(user_id, position);
+		CREATE INDEX idx_queue_entries_entry ON queue_entries(entry_id);
+		`,
+	},
+	{
+		Version:     12,
+		Description: "entry content in its own table",
+		// A generated column cannot read entry_content, so the search vector
+		// becomes a plain column kept up to date by triggers on both tables.
+		SQL: `
+		CREATE TABLE entry_content (
+			entry_id BIGINT PRIMARY KEY,
+			content TEXT NOT NULL
+		);
+
+		INSERT INTO entry_content (entry_id, content)
+			SELECT id, content FROM entries WHERE content IS NOT NULL AND content <> '';
+
+		CREATE FUNCTION entry_search(title TEXT, author TEXT, content TEXT) RETURNS tsvector
+		LANGUAGE SQL IMMUTABLE AS $$
+			SELECT setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+				setweight(to_tsvector('english', COALESCE(author, '')), 'B') ||
+				setweight(to_tsvector('english', strip_html(content)), 'D')
+		$$;
+
+		ALTER TABLE entries DROP COLUMN search;
+		ALTER TABLE entries DROP COLUMN content;
+		ALTER TABLE entries ADD COLUMN search tsvector;
+		UPDATE entries SET search = entry_search(title, author,
+			(SELECT content FROM entry_content WHERE entry_id = entries.id));
+		CREATE INDEX idx_entries_search ON entries USING GIN (search);
+
+		CREATE FUNCTION entries_search_update() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			NEW.search := entry_search(NEW.title, NEW.author,
+				(SELECT content FROM entry_content WHERE entry_id = NEW.id));
+			RETURN NEW;
+		END
+		$$;
+
+		CREATE TRIGGER entries_search BEFORE INSERT OR UPDATE OF title, author ON entries
+			FOR EACH ROW EXECUTE FUNCTION entries_search_update();
+
+		CREATE FUNCTION entry_content_search_update() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				UPDATE entries SET search = entry_search(title, author, NULL) WHERE id = OLD.entry_id;
+			ELSE
+				UPDATE entries SET search = entry_search(title, author, NEW.content) WHERE id = NEW.entry_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$;
+
+		CREATE TRIGGER entry_content_search AFTER INSERT OR UPDATE OR DELETE ON entry_content
+			FOR EACH ROW EXECUTE FUNCTION entry_content_search_update();
+		`,
+	},
+	{
+		Version:     13,
+		Description: "cross-feed duplicate groups",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN canonical_link TEXT NOT NULL DEFAULT '';
+		ALTER TABLE entries ADD COLUMN title_key TEXT NOT NULL DEFAULT '';
+		ALTER TABLE entries ADD COLUMN group_id BIGINT;
+
+		CREATE INDEX idx_entries_canonical_link ON entries(canonical_link);
+		CREATE INDEX idx_entries_title_key ON entries(title_key);
+		CREATE INDEX idx_entries_group_id ON entries(group_id);
+		`,
+		Backfill: backfillDuplicates,
+	},
+	{
+		Version:     14,
+		Description: "original entry links",
+		SQL: `
+		ALTER TABLE entries ADD COLUMN original_link TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		Version:     15,
+		Description: "saved views",
+		SQL: `
+		CREATE TABLE views (
+			user_id BIGINT NOT NULL,
+			name TEXT NOT NULL,
+			filter TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			PRIMARY KEY (user_id, name)
+		);
+		`,
+	},
+	{
+		Version:     16,
+		Description: "index entries once, from entry_content",
+		// The search vector moves to entry_content, so a new entry is indexed
+		// once when its content row is inserted rather than written to entries
+		// a second time. Every entry now has an entry_content row.
+		SQL: `
+		DROP TRIGGER entries_search ON entries;
+		DROP TRIGGER entry_content_search ON entry_content;
+		ALTER TABLE entries DROP COLUMN search;
+
+		INSERT INTO entry_content (entry_id, content)
+			SELECT id, '' FROM entries WHERE id NOT IN (SELECT entry_id FROM entry_content);
+
+		ALTER TABLE entry_content ADD COLUMN search tsvector;
+		UPDATE entry_content SET search = entry_search(entries.title, entries.author, entry_content.content)
+			FROM entries WHERE entries.id = entry_content.entry_id;
+		CREATE INDEX idx_entry_content_search ON entry_content USING GIN (search);
+
+		CREATE OR REPLACE FUNCTION entry_content_search_update() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			SELECT entry_search(title, author, NEW.content) INTO NEW.search
+				FROM entries WHERE id = NEW.entry_id;
+			RETURN NEW;
+		END
+		$$;
+
+		CREATE TRIGGER entry_content_search BEFORE INSERT OR UPDATE OF content ON entry_content
+			FOR EACH ROW EXECUTE FUNCTION entry_content_search_update();
+
+		CREATE OR REPLACE FUNCTION entries_search_update() RETURNS trigger
+		LANGUAGE plpgsql AS $$
+		BEGIN
+			UPDATE entry_content SET search = entry_search(NEW.title, NEW.author, content)
+				WHERE entry_id = NEW.id;
+			RETURN NULL;
+		END
+		$$;
+
+		CREATE TRIGGER entries_search AFTER UPDATE OF title, author ON entries
+			FOR EACH ROW EXECUTE FUNCTION entries_search_update();
+		`,
+	},
+	{
+		Version:     17,
+		Description: "recompute duplicate keys",
+		Backfill:    refreshDuplicateKeys,
+	},
+}
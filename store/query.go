@@ -1,9 +1,11 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -86,3 +88,254 @@ func BuildQueryOptions(limit, offset int, unread bool, since, tag string) (Query
 
 	return opts, nil
 }
+
+// ReadState filters entries by read status.
+type ReadState string
+
+// Read-state filters.
+const (
+	ReadAny    ReadState = "any"
+	ReadUnread ReadState = "unread"
+	ReadRead   ReadState = "read"
+)
+
+// SortField is a column entries can be ordered by.
+type SortField string
+
+// Sort fields. Ties are broken by entry ID in the same direction.
+const (
+	SortPublished SortField = "published"
+	SortTitle     SortField = "title"
+	SortFeed      SortField = "feed"
+	SortID        SortField = "id"   // Insertion order
+	SortRead      SortField = "read" // Time marked read; only read entries with a read time
+)
+
+// SortOrder is the sort direction.
+type SortOrder string
+
+// Sort directions.
+const (
+	SortDesc SortOrder = "desc"
+	SortAsc  SortOrder = "asc"
+)
+
+// sortColumns maps sort fields to SQL expressions.
+var sortColumns = map[SortField]string{
+	SortPublished: "entries.published",
+	SortTitle:     "COALESCE(LOWER(entries.title), '')",
+	SortFeed:      "entries.feed_id",
+	SortID:        "entries.id",
+	SortRead:      "st.read_at",
+}
+
+// dateLayouts are the absolute date formats accepted by ParseDate.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseDate parses an absolute date such as "2026-01-01" or an RFC 3339
+// timestamp. Dates without a zone are in local time.
+func ParseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s (expected YYYY-MM-DD or RFC 3339)", s)
+}
+
+// Validate checks the read state, sort field, sort order and cursor.
+func (o QueryOptions) Validate() error {
+	if _, err := o.readState(); err != nil {
+		return err
+	}
+	if _, err := o.orderBy(); err != nil {
+		return err
+	}
+	if o.Cursor != "" && o.Offset > 0 {
+		return errors.New("cursor and offset cannot be combined")
+	}
+	if err := o.validateFields(); err != nil {
+		return err
+	}
+	_, _, err := o.cursorFilter()
+	return err
+}
+
+// readState resolves Read and the UnreadOnly shorthand.
+func (o QueryOptions) readState() (ReadState, error) {
+	switch o.Read {
+	case "", ReadAny:
+		if o.UnreadOnly {
+			return ReadUnread, nil
+		}
+		return ReadAny, nil
+	case ReadUnread:
+		return ReadUnread, nil
+	case ReadRead:
+		if o.UnreadOnly {
+			return "", fmt.Errorf("conflicting read filters: unread and %s", o.Read)
+		}
+		return ReadRead, nil
+	default:
+		return "", fmt.Errorf("invalid read state: %s (expected read, unread or any)", o.Read)
+	}
+}
+
+// filter returns the WHERE conditions (each prefixed with " AND ") and
+// arguments for every filter in o. Read state, stars and tags are those of
+// user; the query must join its state with stateJoin. Sort does not narrow
+// the match; see listFilter.
+func (o QueryOptions) filter(user int64) (string, []interface{}, error) {
+	return o.conditions(user, false)
+}
+
+// listFilter is filter for listing entries in Sort order: entries the sort
+// cannot order or page are left out as well.
+func (o QueryOptions) listFilter(user int64) (string, []interface{}, error) {
+	return o.conditions(user, true)
+}
+
+// conditions implements filter and listFilter.
+func (o QueryOptions) conditions(user int64, sorted bool) (string, []interface{}, error) {
+	var b strings.Builder
+	args := []interface{}{}
+
+	read, err := o.readState()
+	if err != nil {
+		return "", nil, err
+	}
+	switch read {
+	case ReadUnread:
+		b.WriteString(" AND COALESCE(st.is_read, 0) = 0")
+	case ReadRead:
+		b.WriteString(" AND st.is_read = 1")
+	}
+
+	if o.StarredOnly {
+		b.WriteString(" AND st.starred_at IS NOT NULL")
+	}
+
+	if o.ReadSince != nil {
+		b.WriteString(" AND st.read_at >= ?")
+		args = append(args, *o.ReadSince)
+	}
+
+	// Entries without a read time cannot be ordered or paged by it
+	if sorted && o.Sort == SortRead {
+		b.WriteString(" AND st.read_at IS NOT NULL")
+	}
+
+	if o.OlderThanID > 0 {
+		b.WriteString(" AND entries.id < ?")
+		args = append(args, o.OlderThanID)
+	}
+
+	if len(o.FeedIDs) > 0 {
+		b.WriteString(" AND entries.feed_id IN (" + placeholders(len(o.FeedIDs)) + ")")
+		for _, id := range o.FeedIDs {
+			args = append(args, id)
+		}
+	}
+
+	if len(o.Categories) > 0 {
+		b.WriteString(" AND entries.feed_id IN (SELECT id FROM feeds WHERE category IN (" + placeholders(len(o.Categories)) + "))")
+		for _, c := range o.Categories {
+			args = append(args, c)
+		}
+	}
+
+	if o.SinceTime != nil {
+		b.WriteString(" AND entries.published >= ?")
+		args = append(args, *o.SinceTime)
+	}
+
+	if o.After != nil {
+		b.WriteString(" AND entries.published >= ?")
+		args = append(args, *o.After)
+	}
+
+	if o.Before != nil {
+		b.WriteString(" AND entries.published < ?")
+		args = append(args, *o.Before)
+	}
+
+	if tags := o.tags(); len(tags) > 0 {
+		cond, tagArgs := tagFilter(user, tags, o.AllTags)
+		b.WriteString(cond)
+		args = append(args, tagArgs...)
+	}
+
+	// The representative of each group is picked among the entries matching
+	// every other filter, so a filter never hides a whole group
+	if o.Dedupe {
+		conds := b.String()
+		b.WriteString(" AND entries.id IN (SELECT MIN(entries.id) FROM entries" + stateJoin(user) + " WHERE 1=1" + conds + " GROUP BY COALESCE(entries.group_id, entries.id))")
+		args = append(args, args...)
+	}
+
+	return b.String(), args, nil
+}
+
+// sortSpec returns Sort and Order with defaults applied.
+func (o QueryOptions) sortSpec() (SortField, SortOrder, error) {
+	field := o.Sort
+	if field == "" {
+		field = SortPublished
+	}
+	if _, ok := sortColumns[field]; !ok {
+		return "", "", fmt.Errorf("invalid sort field: %s (expected published, title, feed, id or read)", field)
+	}
+
+	order := o.Order
+	if order == "" {
+		order = SortDesc
+	}
+	if order != SortAsc && order != SortDesc {
+		return "", "", fmt.Errorf("invalid sort order: %s (expected asc or desc)", o.Order)
+	}
+
+	return field, order, nil
+}
+
+// orderBy returns the ORDER BY expression for Sort and Order.
+func (o QueryOptions) orderBy() (string, error) {
+	field, order, err := o.sortSpec()
+	if err != nil {
+		return "", err
+	}
+	column := sortColumns[field]
+
+	dir := "DESC"
+	if order == SortAsc {
+		dir = "ASC"
+	}
+
+	if field == SortID {
+		return column + " " + dir, nil
+	}
+	return column + " " + dir + ", entries.id " + dir, nil
+}
+
+// paginate appends LIMIT and OFFSET to query.
+func (o QueryOptions) paginate(query string, args []interface{}) (string, []interface{}) {
+	if o.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, o.Limit)
+	}
+
+	if o.Offset > 0 {
+		// SQLite only accepts OFFSET after a LIMIT; PostgreSQL rejects -1
+		if o.Limit <= 0 {
+			query += " LIMIT 9223372036854775807"
+		}
+		query += " OFFSET ?"
+		args = append(args, o.Offset)
+	}
+
+	return query, args
+}
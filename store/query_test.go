@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/robertmeta/feed-cli/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -217,3 +218,91 @@ func TestBuildQueryOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDate(t *testing.T) {
+	d, err := ParseDate("2026-01-02")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local), d)
+
+	d, err = ParseDate("2026-01-02T03:04:05Z")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Unix(), d.Unix())
+
+	_, err = ParseDate("01/02/2026")
+	assert.Error(t, err)
+}
+
+// newQueryStore creates two feeds in different categories with entries on
+// consecutive days in January 2026.
+func newQueryStore(t *testing.T) *Store {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	feeds := []*model.Feed{
+		{URL: "https://example.com/a", Title: "A", Category: "security"},
+		{URL: "https://example.com/b", Title: "B", Category: "golang"},
+	}
+	for _, f := range feeds {
+		require.NoError(t, s.SaveFeed(f))
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+	entries := []*model.Entry{
+		{FeedID: feeds[0].ID, GUID: "a1", Title: "beta", Published: day(1), IsRead: true},
+		{FeedID: feeds[0].ID, GUID: "a2", Title: "Alpha", Published: day(2)},
+		{FeedID: feeds[1].ID, GUID: "b1", Title: "delta", Published: day(3), IsRead: true},
+		{FeedID: feeds[1].ID, GUID: "b2", Title: "gamma", Published: day(4)},
+	}
+	for _, e := range entries {
+		require.NoError(t, s.SaveEntry(e))
+	}
+
+	return s
+}
+
+func TestGetEntries_Filters(t *testing.T) {
+	s := newQueryStore(t)
+	defer s.Close()
+
+	unix := func(d int) *int64 {
+		u := time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC).Unix()
+		return &u
+	}
+
+	tests := []struct {
+		name string
+		opts QueryOptions
+		want []string
+	}{
+		{"default newest first", QueryOptions{}, []string{"b2", "b1", "a2", "a1"}},
+		{"feed", QueryOptions{FeedIDs: []int64{1}}, []string{"a2", "a1"}},
+		{"feeds", QueryOptions{FeedIDs: []int64{1, 2}}, []string{"b2", "b1", "a2", "a1"}},
+		{"category", QueryOptions{Categories: []string{"golang"}}, []string{"b2", "b1"}},
+		{"date range", QueryOptions{After: unix(2), Before: unix(4)}, []string{"b1", "a2"}},
+		{"read", QueryOptions{Read: ReadRead}, []string{"b1", "a1"}},
+		{"unread", QueryOptions{Read: ReadUnread}, []string{"b2", "a2"}},
+		{"unread shorthand", QueryOptions{UnreadOnly: true}, []string{"b2", "a2"}},
+		{"oldest first", QueryOptions{Order: SortAsc}, []string{"a1", "a2", "b1", "b2"}},
+		{"title", QueryOptions{Sort: SortTitle, Order: SortAsc}, []string{"a2", "a1", "b1", "b2"}},
+		{"feed then id", QueryOptions{Sort: SortFeed, Order: SortDesc}, []string{"b2", "b1", "a2", "a1"}},
+		{"offset without limit", QueryOptions{Offset: 3}, []string{"a1"}},
+		{"combined", QueryOptions{Categories: []string{"security"}, Read: ReadUnread, After: unix(1)}, []string{"a2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.GetEntries(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, entryGUIDs(entries))
+		})
+	}
+}
+
+func TestQueryOptions_Validate(t *testing.T) {
+	assert.NoError(t, QueryOptions{}.Validate())
+	assert.NoError(t, QueryOptions{Read: ReadAny, Sort: SortTitle, Order: SortAsc}.Validate())
+	assert.Error(t, QueryOptions{Read: "maybe"}.Validate())
+	assert.Error(t, QueryOptions{Read: ReadRead, UnreadOnly: true}.Validate())
+	assert.Error(t, QueryOptions{Sort: "author"}.Validate())
+	assert.Error(t, QueryOptions{Order: "sideways"}.Validate())
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// QueueItem is an entry in the current user's read-later queue.
+type QueueItem struct {
+	Position int       `json:"position"` // 1 is next
+	AddedAt  time.Time `json:"added_at"`
+	*model.Entry
+}
+
+// QueueEntries adds entries to the current user's read-later queue, at the
+// end or, with top, in front of everything else, keeping their order. Missing
+// and already queued entries are skipped. It returns how many were added.
+func (s *Store) QueueEntries(ids []int64, top bool) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queue, err := s.loadQueue(tx)
+	if err != nil {
+		return 0, err
+	}
+	queued := make(map[int64]bool, len(queue))
+	for _, id := range queue {
+		queued[id] = true
+	}
+
+	added := []int64{}
+	for _, id := range ids {
+		if queued[id] {
+			continue
+		}
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM entries WHERE id = ?", id).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("failed to get entry: %w", err)
+		}
+		if exists == 0 {
+			continue
+		}
+		queued[id] = true
+		added = append(added, id)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now().Unix()
+	for _, id := range added {
+		// Positions are rewritten below
+		if _, err := tx.Exec("INSERT INTO queue_entries (user_id, entry_id, position, added_at) VALUES (?, ?, 0, ?)", s.userID, id, now); err != nil {
+			return 0, fmt.Errorf("failed to queue entry %d: %w", id, err)
+		}
+	}
+
+	if top {
+		queue = append(added, queue...)
+	} else {
+		queue = append(queue, added...)
+	}
+	if err := s.saveQueue(tx, queue); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit queue: %w", err)
+	}
+	return len(added), nil
+}
+
+// DequeueEntries removes entries from the current user's queue and returns
+// how many were queued.
+func (s *Store) DequeueEntries(ids []int64) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed, err := s.dequeue(tx, ids)
+	if err != nil || len(removed) == 0 {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit queue: %w", err)
+	}
+	return len(removed), nil
+}
+
+// FinishQueueEntries removes entries from the current user's queue and marks
+// them read in one transaction, returning how many changed to read and how
+// many were dequeued. Entries that were not queued are left alone. Marking
+// read is journaled like MarkEntriesRead; undoing it does not requeue the
+// entries.
+func (s *Store) FinishQueueEntries(ids []int64) (read, removed int, err error) {
+	read, err = s.journaled(func(tx *txn) (int, int64, error) {
+		dequeued, err := s.dequeue(tx, ids)
+		if err != nil || len(dequeued) == 0 {
+			return 0, s.opID, err
+		}
+		removed = len(dequeued)
+
+		args := make([]interface{}, 0, len(dequeued))
+		for _, id := range dequeued {
+			args = append(args, id)
+		}
+		return s.markReadTx(tx, " AND entries.id IN ("+placeholders(len(args))+")", args, true)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return read, removed, nil
+}
+
+// dequeue removes the queued ones of ids from the current user's queue,
+// renumbering the rest, and returns them in queue order.
+func (s *Store) dequeue(tx *txn, ids []int64) ([]int64, error) {
+	queue, err := s.loadQueue(tx)
+	if err != nil {
+		return nil, err
+	}
+	remove := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	kept := make([]int64, 0, len(queue))
+	removed := []int64{}
+	for _, id := range queue {
+		if !remove[id] {
+			kept = append(kept, id)
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM queue_entries WHERE user_id = ? AND entry_id = ?", s.userID, id); err != nil {
+			return nil, fmt.Errorf("failed to dequeue entry %d: %w", id, err)
+		}
+		removed = append(removed, id)
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+
+	if err := s.saveQueue(tx, kept); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// MoveQueueEntry moves a queued entry to position (1 is next), shifting the
+// entries in between, and returns its new position. Positions past the end
+// move it to the end.
+func (s *Store) MoveQueueEntry(entryID int64, position int) (int, error) {
+	if position < 1 {
+		return 0, fmt.Errorf("invalid queue position: %d", position)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queue, err := s.loadQueue(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	from := -1
+	for i, id := range queue {
+		if id == entryID {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return 0, fmt.Errorf("entry %d is not queued", entryID)
+	}
+
+	to := position - 1
+	if to >= len(queue) {
+		to = len(queue) - 1
+	}
+	moved := make([]int64, 0, len(queue))
+	moved = append(moved, queue[:from]...)
+	moved = append(moved, queue[from+1:]...)
+	moved = append(moved[:to], append([]int64{entryID}, moved[to:]...)...)
+
+	if err := s.saveQueue(tx, moved); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit queue: %w", err)
+	}
+	return to + 1, nil
+}
+
+// GetQueue returns the current user's queue in order; limit 0 returns all of it.
+func (s *Store) GetQueue(limit int) ([]*QueueItem, error) {
+	query := "SELECT " + entryColumns + `, q.position, q.added_at
+		FROM queue_entries AS q JOIN entries ON entries.id = q.entry_id` + s.stateJoin() + `
+		WHERE q.user_id = ?
+		ORDER BY q.position`
+	args := []interface{}{s.userID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*QueueItem{}
+	entries := []*model.Entry{}
+	for rows.Next() {
+		item := &QueueItem{}
+		var addedAt int64
+		if item.Entry, err = scanEntry(rows, &item.Position, &addedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		item.AddedAt = unixToTime(addedAt)
+		items = append(items, item)
+		entries = append(entries, item.Entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadTags(entries); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// loadQueue returns the current user's queued entry IDs in order.
+func (s *Store) loadQueue(tx *txn) ([]int64, error) {
+	rows, err := tx.Query("SELECT entry_id FROM queue_entries WHERE user_id = ? ORDER BY position, added_at, entry_id", s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// saveQueue numbers the current user's queue 1..n in the order of ids.
+// Queues are short, so every position is simply rewritten.
+func (s *Store) saveQueue(tx *txn, ids []int64) error {
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE queue_entries SET position = ? WHERE user_id = ? AND entry_id = ? AND position <> ?", i+1, s.userID, id, i+1); err != nil {
+			return fmt.Errorf("failed to reorder queue: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queueGUIDs(t *testing.T, s *Store) []string {
+	t.Helper()
+	items, err := s.GetQueue(0)
+	require.NoError(t, err)
+	guids := []string{}
+	for i, item := range items {
+		assert.Equal(t, i+1, item.Position)
+		guids = append(guids, item.GUID)
+	}
+	return guids
+}
+
+func TestQueue_AddAndMove(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+	a, b, c := entries[0].ID, entries[1].ID, entries[2].ID
+
+	n, err := s.QueueEntries([]int64{b, a, b, 9999}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "Duplicates and missing entries are skipped")
+
+	n, err = s.QueueEntries([]int64{c, a}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"c", "b", "a"}, queueGUIDs(t, s))
+
+	position, err := s.MoveQueueEntry(c, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, position)
+	assert.Equal(t, []string{"b", "a", "c"}, queueGUIDs(t, s))
+	_, err = s.MoveQueueEntry(a, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, queueGUIDs(t, s))
+	position, err = s.MoveQueueEntry(a, 99)
+	require.NoError(t, err)
+	assert.Equal(t, 3, position, "Clamped to the end")
+	assert.Equal(t, []string{"b", "c", "a"}, queueGUIDs(t, s))
+
+	_, err = s.MoveQueueEntry(a, 0)
+	assert.Error(t, err)
+	_, err = s.MoveQueueEntry(9999, 1)
+	assert.Error(t, err)
+
+	next, err := s.GetQueue(1)
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	assert.Equal(t, "b", next[0].GUID)
+
+	// Other users have their own queue
+	addUsers(t, s, "alice")
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	assert.Empty(t, queueGUIDs(t, s))
+}
+
+func TestQueue_DoneAndRemove(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+	a, b, c := entries[0].ID, entries[1].ID, entries[2].ID
+
+	_, err := s.QueueEntries([]int64{a, b, c}, false)
+	require.NoError(t, err)
+
+	// The queue is independent of read state
+	require.NoError(t, s.MarkEntryRead(c, true))
+	assert.Equal(t, []string{"a", "b", "c"}, queueGUIDs(t, s))
+
+	read, removed, err := s.FinishQueueEntries([]int64{a, c})
+	require.NoError(t, err)
+	assert.Equal(t, 1, read, "c was already read")
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, []string{"b"}, queueGUIDs(t, s))
+
+	entry, err := s.GetEntry(a)
+	require.NoError(t, err)
+	assert.True(t, entry.IsRead)
+
+	n, err := s.DequeueEntries([]int64{b, a})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, queueGUIDs(t, s))
+
+	entry, err = s.GetEntry(b)
+	require.NoError(t, err)
+	assert.False(t, entry.IsRead, "Removing does not mark read")
+}
+
+func TestQueue_FinishOnlyQueued(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+	a, b := entries[0].ID, entries[1].ID
+
+	// Nothing queued: nothing read, no operation
+	read, removed, err := s.FinishQueueEntries([]int64{a, b})
+	require.NoError(t, err)
+	assert.Zero(t, read)
+	assert.Zero(t, removed)
+	assert.Zero(t, s.OperationID())
+
+	_, err = s.QueueEntries([]int64{a}, false)
+	require.NoError(t, err)
+	read, removed, err = s.FinishQueueEntries([]int64{a, b})
+	require.NoError(t, err)
+	assert.Equal(t, 1, read)
+	assert.Equal(t, 1, removed)
+
+	entry, err := s.GetEntry(b)
+	require.NoError(t, err)
+	assert.False(t, entry.IsRead, "b was never queued")
+
+	ops, err := s.GetOperations(0)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, map[string]int{ActionRead: 1}, ops[0].Changes)
+}
+
+func TestQueue_SurvivesRetention(t *testing.T) {
+	s, _, entries := newPruneStore(t)
+	defer s.Close()
+
+	_, err := s.QueueEntries([]int64{entries[4].ID}, false)
+	require.NoError(t, err)
+
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Deleted)
+	assert.Equal(t, []string{"e4"}, queueGUIDs(t, s))
+}
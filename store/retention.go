@@ -0,0 +1,163 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy limits how much history a feed keeps. Zero fields are unlimited.
+type RetentionPolicy struct {
+	Keep int `json:"keep,omitempty"` // Keep only the newest N entries
+	Days int `json:"days,omitempty"` // Drop entries older than N days
+}
+
+// PruneOptions controls Prune. Default applies to feeds without their own
+// retention settings; each per-feed field overrides the matching default.
+type PruneOptions struct {
+	Default RetentionPolicy
+	Force   bool // Also delete unread entries
+	DryRun  bool // Report what would be deleted without deleting
+}
+
+// PruneFeedResult is the number of entries pruned from one feed.
+type PruneFeedResult struct {
+	FeedID  int64  `json:"feed_id"`
+	Title   string `json:"title"`
+	Entries int    `json:"entries"`
+}
+
+// PruneResult summarises a Prune run.
+type PruneResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Deleted int                `json:"deleted"`
+	Feeds   []*PruneFeedResult `json:"feeds"`
+}
+
+// SetFeedRetention stores a feed's retention policy. A zero policy clears it
+// so the feed falls back to the global default.
+func (s *Store) SetFeedRetention(feedID int64, p RetentionPolicy) error {
+	if p.Keep < 0 || p.Days < 0 {
+		return errors.New("retention values must not be negative")
+	}
+
+	result, err := s.db.Exec(
+		"UPDATE feeds SET retain_count = NULLIF(?, 0), retain_days = NULLIF(?, 0) WHERE id = ?",
+		p.Keep, p.Days, feedID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set retention: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("feed not found")
+	}
+	return nil
+}
+
+// pruneCandidates selects entries that fall outside their feed's retention
+// policy. Entries starred by any user are never candidates; entries some user
+// has not read only when forced. Arguments: default keep, default days,
+// force, cutoff base (now, Unix).
+const pruneCandidates = `
+	WITH policy AS (
+		SELECT id AS feed_id,
+			COALESCE(retain_count, ?) AS keep,
+			COALESCE(retain_days, ?) AS days
+		FROM feeds
+	),
+	ranked AS (
+		SELECT entries.id, entries.feed_id, entries.guid, entries.published,
+			ROW_NUMBER() OVER (PARTITION BY entries.feed_id ORDER BY entries.published DESC, entries.id DESC) AS position
+		FROM entries
+	)
+	SELECT ranked.id, ranked.feed_id, ranked.guid
+	FROM ranked JOIN policy ON policy.feed_id = ranked.feed_id
+	WHERE ranked.id NOT IN (` + keptEntries + `)
+		AND (? OR ranked.id IN (` + readByAll + `))
+		AND ((policy.keep > 0 AND ranked.position > policy.keep)
+			OR (policy.days > 0 AND ranked.published < CAST(? AS BIGINT) - policy.days * 86400))`
+
+// readByAll selects entries every user has read. A user without a state row
+// for an entry has not read it.
+const readByAll = `
+	SELECT entry_id FROM entry_state WHERE is_read = 1
+	GROUP BY entry_id HAVING COUNT(*) = (SELECT COUNT(*) FROM users)`
+
+// Prune deletes entries outside their feed's retention policy in a single
+// transaction, leaving a (feed_id, guid) tombstone for each so later updates
+// do not re-insert them.
+func (s *Store) Prune(opts PruneOptions) (*PruneResult, error) {
+	if opts.Default.Keep < 0 || opts.Default.Days < 0 {
+		return nil, errors.New("retention values must not be negative")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+
+	// Temporary tables are per-connection; the transaction pins one. The
+	// candidates are fixed up front so the counts match what is deleted.
+	if _, err := tx.Exec("CREATE TEMP TABLE prune_ids (id BIGINT, feed_id BIGINT, guid TEXT)"); err != nil {
+		return nil, fmt.Errorf("failed to select entries to prune: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO prune_ids (id, feed_id, guid) "+pruneCandidates,
+		opts.Default.Keep, opts.Default.Days, opts.Force, now,
+	); err != nil {
+		return nil, fmt.Errorf("failed to select entries to prune: %w", err)
+	}
+
+	result := &PruneResult{DryRun: opts.DryRun, Feeds: []*PruneFeedResult{}}
+
+	rows, err := tx.Query(`
+		SELECT p.feed_id, COALESCE(f.title, ''), COUNT(*)
+		FROM prune_ids p LEFT JOIN feeds f ON f.id = p.feed_id
+		GROUP BY p.feed_id, f.title
+		ORDER BY p.feed_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count entries to prune: %w", err)
+	}
+	for rows.Next() {
+		feed := &PruneFeedResult{}
+		if err := rows.Scan(&feed.FeedID, &feed.Title, &feed.Entries); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan prune count: %w", err)
+		}
+		result.Feeds = append(result.Feeds, feed)
+		result.Deleted += feed.Entries
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count entries to prune: %w", err)
+	}
+
+	// Rolling back also drops the temporary table
+	if opts.DryRun || result.Deleted == 0 {
+		return result, nil
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO entry_tombstones (feed_id, guid, deleted_at) SELECT feed_id, guid, CAST(? AS BIGINT) FROM prune_ids WHERE 1 = 1 ON CONFLICT DO NOTHING", []interface{}{now}},
+		{"DELETE FROM entry_tags WHERE entry_id IN (SELECT id FROM prune_ids)", nil},
+		{"DELETE FROM entry_state WHERE entry_id IN (SELECT id FROM prune_ids)", nil},
+		{"DELETE FROM entry_content WHERE entry_id IN (SELECT id FROM prune_ids)", nil},
+		{"DELETE FROM entries WHERE id IN (SELECT id FROM prune_ids)", nil},
+		{"DROP TABLE prune_ids", nil},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return nil, fmt.Errorf("failed to prune entries: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prune: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPruneStore creates a feed with five read entries published one day apart,
+// newest first ("e0" is today, "e4" four days ago).
+func newPruneStore(t *testing.T) (*Store, *model.Feed, []*model.Entry) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	now := time.Now()
+	entries := []*model.Entry{}
+	for i := 0; i < 5; i++ {
+		entries = append(entries, &model.Entry{
+			GUID:      fmt.Sprintf("e%d", i),
+			Title:     fmt.Sprintf("Entry %d", i),
+			Published: now.Add(-time.Duration(i) * 24 * time.Hour),
+			IsRead:    true,
+		})
+	}
+	_, err = s.SaveEntries(feed.ID, entries)
+	require.NoError(t, err)
+
+	return s, feed, entries
+}
+
+func TestPrune_KeepAndMaxAge(t *testing.T) {
+	s, _, _ := newPruneStore(t)
+	defer s.Close()
+
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 3}, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+	require.Len(t, result.Feeds, 1)
+	assert.Equal(t, "Test", result.Feeds[0].Title)
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, remaining, 5, "Dry run should not delete")
+
+	result, err = s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 4, Days: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+
+	remaining, err = s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e0", "e1", "e2"}, entryGUIDs(remaining))
+
+	// No policy, nothing to prune
+	result, err = s.Prune(PruneOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted)
+}
+
+func TestPrune_SkipsUnreadAndStarred(t *testing.T) {
+	s, _, entries := newPruneStore(t)
+	defer s.Close()
+
+	require.NoError(t, s.MarkEntryRead(entries[3].ID, false))
+	_, err := s.StarEntries([]int64{entries[4].ID}, true)
+	require.NoError(t, err)
+
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e0", "e3", "e4"}, entryGUIDs(remaining))
+
+	// Force removes unread entries, but never starred ones
+	result, err = s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}, Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+
+	remaining, err = s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e0", "e4"}, entryGUIDs(remaining))
+}
+
+func TestPrune_SkipsEntriesAnyUserHasNotRead(t *testing.T) {
+	s, _, entries := newPruneStore(t)
+	defer s.Close()
+
+	// Bob has read only e4; the default user has read everything
+	addUsers(t, s, "bob")
+	_, err := s.SetUser("bob")
+	require.NoError(t, err)
+	require.NoError(t, s.MarkEntryRead(entries[4].ID, true))
+
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e0", "e1", "e2", "e3"}, entryGUIDs(remaining))
+
+	// Bob reading an entry the default user has marked unread again does not
+	// make it prunable either
+	_, err = s.SetUser(DefaultUser)
+	require.NoError(t, err)
+	require.NoError(t, s.MarkEntryRead(entries[3].ID, false))
+	_, err = s.SetUser("bob")
+	require.NoError(t, err)
+	require.NoError(t, s.MarkEntryRead(entries[3].ID, true))
+
+	result, err = s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted)
+
+	result, err = s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}, Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Deleted)
+}
+
+func TestPrune_PerFeedPolicy(t *testing.T) {
+	s, feed, _ := newPruneStore(t)
+	defer s.Close()
+
+	require.NoError(t, s.SetFeedRetention(feed.ID, RetentionPolicy{Keep: 2}))
+	assert.Error(t, s.SetFeedRetention(9999, RetentionPolicy{Keep: 2}))
+
+	got, err := s.GetFeed(feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.RetainCount)
+
+	// The feed's keep overrides the default
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Deleted)
+
+	// Clearing falls back to the default
+	require.NoError(t, s.SetFeedRetention(feed.ID, RetentionPolicy{}))
+	got, err = s.GetFeed(feed.ID)
+	require.NoError(t, err)
+	assert.Zero(t, got.RetainCount)
+}
+
+func TestPrune_TombstonesBlockReinsert(t *testing.T) {
+	s, feed, entries := newPruneStore(t)
+	defer s.Close()
+
+	_, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 2}})
+	require.NoError(t, err)
+
+	// The next update sees the same items again
+	again := []*model.Entry{}
+	for _, e := range entries {
+		again = append(again, &model.Entry{GUID: e.GUID, Title: e.Title, Published: e.Published})
+	}
+	again = append(again, &model.Entry{GUID: "new", Title: "New", Published: time.Now()})
+
+	inserted, err := s.SaveEntries(feed.ID, again)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted, "Only the genuinely new entry is inserted")
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"new", "e0", "e1"}, entryGUIDs(remaining))
+
+	// Saving a single entry does not bring one back either
+	err = s.SaveEntry(&model.Entry{FeedID: feed.ID, GUID: "e4", Title: "Entry 4", Published: entries[4].Published})
+	assert.ErrorIs(t, err, ErrEntryPruned)
+	require.NoError(t, s.SaveEntry(&model.Entry{FeedID: feed.ID, GUID: "e5", Title: "Entry 5", Published: time.Now()}))
+}
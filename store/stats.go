@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// Fetch health statuses.
+const (
+	HealthOK           = "ok"
+	HealthFailing      = "failing"
+	HealthNeverFetched = "never_fetched"
+)
+
+// FetchHealth describes how recent fetches of a feed went.
+type FetchHealth struct {
+	Status              string     `json:"status"`
+	LastFetched         *time.Time `json:"last_fetched,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+}
+
+// FeedStats is a feed with aggregate entry counts and fetch health.
+type FeedStats struct {
+	*model.Feed
+	Entries      int         `json:"entries"`
+	Unread       int         `json:"unread"`
+	Starred      int         `json:"starred"`
+	LastEntry    *time.Time  `json:"last_entry,omitempty"`
+	PostsPerWeek float64     `json:"posts_per_week"`
+	Health       FetchHealth `json:"health"`
+}
+
+// Stats holds database-wide totals.
+type Stats struct {
+	Feeds         int        `json:"feeds"`
+	FailingFeeds  int        `json:"failing_feeds"`
+	Entries       int        `json:"entries"`
+	Unread        int        `json:"unread"`
+	Starred       int        `json:"starred"`
+	Tags          int        `json:"tags"`
+	OldestEntry   *time.Time `json:"oldest_entry,omitempty"`
+	NewestEntry   *time.Time `json:"newest_entry,omitempty"`
+	SchemaVersion int        `json:"schema_version"`
+	DBSizeBytes   int64      `json:"db_size_bytes"`
+	DBFreeBytes   int64      `json:"db_free_bytes"` // Reclaimable with VACUUM
+}
+
+// RecordFetchError records a failed fetch of a feed and extends its failure streak.
+func (s *Store) RecordFetchError(feedID int64, fetchErr error) error {
+	return s.RecordFetchErrorContext(context.Background(), feedID, fetchErr)
+}
+
+// RecordFetchErrorContext is like RecordFetchError but honours ctx cancellation.
+func (s *Store) RecordFetchErrorContext(ctx context.Context, feedID int64, fetchErr error) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE feeds SET last_error = ?, last_error_at = ?, error_count = error_count + 1 WHERE id = ?",
+		fetchErr.Error(), time.Now().Unix(), feedID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record fetch error: %w", err)
+	}
+	return nil
+}
+
+// GetFeedStats returns every feed with its entry counts and fetch health,
+// aggregated in SQL. Unread and starred counts are the current user's.
+func (s *Store) GetFeedStats() ([]*FeedStats, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + feedColumns + `,
+			COALESCE(last_error, ''), last_error_at, error_count,
+			COALESCE(e.total, 0), COALESCE(e.unread, 0), COALESCE(e.starred, 0),
+			e.first_published, e.last_published
+		FROM feeds LEFT JOIN (
+			SELECT entries.feed_id,
+				COUNT(*) AS total,
+				SUM(CASE WHEN COALESCE(st.is_read, 0) = 0 THEN 1 ELSE 0 END) AS unread,
+				COUNT(st.starred_at) AS starred,
+				MIN(entries.published) AS first_published,
+				MAX(entries.published) AS last_published
+			FROM entries` + s.stateJoin() + ` GROUP BY entries.feed_id
+		) e ON e.feed_id = feeds.id
+		ORDER BY feeds.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*FeedStats{}
+	for rows.Next() {
+		fs := &FeedStats{}
+		var lastErrorAt, firstPublished, lastPublished sql.NullInt64
+		fs.Feed, err = scanFeed(rows,
+			&fs.Health.LastError, &lastErrorAt, &fs.Health.ConsecutiveFailures,
+			&fs.Entries, &fs.Unread, &fs.Starred,
+			&firstPublished, &lastPublished,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed stats: %w", err)
+		}
+
+		fs.LastEntry = nullUnixToTime(lastPublished)
+		fs.PostsPerWeek = postsPerWeek(fs.Entries, firstPublished, lastPublished)
+		fs.Health.LastFetched = fs.Feed.LastUpdated
+		fs.Health.LastErrorAt = nullUnixToTime(lastErrorAt)
+		switch {
+		case fs.Health.ConsecutiveFailures > 0:
+			fs.Health.Status = HealthFailing
+		case fs.Health.LastFetched == nil:
+			fs.Health.Status = HealthNeverFetched
+		default:
+			fs.Health.Status = HealthOK
+		}
+
+		stats = append(stats, fs)
+	}
+
+	return stats, rows.Err()
+}
+
+// postsPerWeek averages entries over the span between the first and last
+// entry, counting spans shorter than a week as one week.
+func postsPerWeek(entries int, first, last sql.NullInt64) float64 {
+	if entries == 0 || !first.Valid || !last.Valid {
+		return 0
+	}
+	const week = 7 * 24 * 60 * 60
+	weeks := math.Max(float64(last.Int64-first.Int64)/week, 1)
+	return math.Round(float64(entries)/weeks*100) / 100
+}
+
+// GetStats returns database-wide totals, aggregated in SQL. Unread, starred
+// and tag counts are the current user's.
+func (s *Store) GetStats() (*Stats, error) {
+	stats := &Stats{}
+	var oldest, newest sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM feeds),
+			(SELECT COUNT(*) FROM feeds WHERE error_count > 0),
+			(SELECT COUNT(*) FROM tags WHERE user_id = ?),
+			COUNT(*), COALESCE(SUM(CASE WHEN COALESCE(st.is_read, 0) = 0 THEN 1 ELSE 0 END), 0), COUNT(st.starred_at),
+			MIN(entries.published), MAX(entries.published)
+		FROM entries`+s.stateJoin(), s.userID,
+	).Scan(&stats.Feeds, &stats.FailingFeeds, &stats.Tags,
+		&stats.Entries, &stats.Unread, &stats.Starred, &oldest, &newest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats: %w", err)
+	}
+	stats.OldestEntry = nullUnixToTime(oldest)
+	stats.NewestEntry = nullUnixToTime(newest)
+
+	if stats.SchemaVersion, err = s.SchemaVersion(); err != nil {
+		return nil, err
+	}
+
+	if stats.DBSizeBytes, stats.DBFreeBytes, err = s.pageStats(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFeedStats(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	busy := &model.Feed{URL: "https://example.com/busy", Title: "Busy"}
+	empty := &model.Feed{URL: "https://example.com/empty", Title: "Empty"}
+	require.NoError(t, s.SaveFeed(busy))
+	require.NoError(t, s.SaveFeed(empty))
+
+	// Eight entries over four weeks
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*model.Entry{}
+	for i := 0; i < 8; i++ {
+		entries = append(entries, &model.Entry{
+			GUID:      fmt.Sprintf("e%d", i),
+			Published: start.Add(time.Duration(i) * 4 * 24 * time.Hour),
+			IsRead:    i < 5,
+		})
+	}
+	_, err = s.SaveEntries(busy.ID, entries)
+	require.NoError(t, err)
+	_, err = s.StarEntries([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+
+	stats, err := s.GetFeedStats()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, "Busy", stats[0].Title)
+	assert.Equal(t, 8, stats[0].Entries)
+	assert.Equal(t, 3, stats[0].Unread)
+	assert.Equal(t, 1, stats[0].Starred)
+	assert.Equal(t, 2.0, stats[0].PostsPerWeek)
+	require.NotNil(t, stats[0].LastEntry)
+	assert.True(t, stats[0].LastEntry.Equal(entries[7].Published))
+	assert.Equal(t, HealthNeverFetched, stats[0].Health.Status)
+
+	assert.Zero(t, stats[1].Entries)
+	assert.Nil(t, stats[1].LastEntry)
+	assert.Zero(t, stats[1].PostsPerWeek)
+}
+
+func TestFetchHealth(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	w := s.NewWriter()
+	require.NoError(t, w.WriteError(context.Background(), feed, errors.New("http status 500")))
+	require.NoError(t, w.WriteError(context.Background(), feed, errors.New("http status 503")))
+	w.Close()
+
+	stats, err := s.GetFeedStats()
+	require.NoError(t, err)
+	health := stats[0].Health
+	assert.Equal(t, HealthFailing, health.Status)
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+	assert.Equal(t, "http status 503", health.LastError)
+	assert.NotNil(t, health.LastErrorAt)
+
+	totals, err := s.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, totals.FailingFeeds)
+
+	// A successful fetch clears the failure streak
+	now := time.Now()
+	feed.LastUpdated = &now
+	_, err = s.SaveFetch(feed, nil)
+	require.NoError(t, err)
+
+	stats, err = s.GetFeedStats()
+	require.NoError(t, err)
+	health = stats[0].Health
+	assert.Equal(t, HealthOK, health.Status)
+	assert.Zero(t, health.ConsecutiveFailures)
+	assert.Empty(t, health.LastError)
+}
+
+func TestGetStats(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	require.NoError(t, s.MarkEntryRead(entries[0].ID, true))
+	_, err := s.StarEntries([]int64{entries[1].ID}, true)
+	require.NoError(t, err)
+	_, err = s.TagEntries([]int64{entries[1].ID}, "go")
+	require.NoError(t, err)
+
+	stats, err := s.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Feeds)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, 2, stats.Unread)
+	assert.Equal(t, 1, stats.Starred)
+	assert.Equal(t, 1, stats.Tags)
+	assert.Equal(t, LatestVersion(), stats.SchemaVersion)
+	assert.Positive(t, stats.DBSizeBytes)
+	require.NotNil(t, stats.NewestEntry)
+	assert.Equal(t, entries[0].Published.Unix(), stats.NewestEntry.Unix())
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// Storage is everything feed-cli needs from a database. *Store implements it
+// on SQLite and PostgreSQL; New and Open pick the backend from the path.
+type Storage interface {
+	Close() error
+
+	// Users
+	AddUser(name string) (*User, error)
+	SetUser(name string) (*User, error)
+	GetUsers() ([]*User, error)
+
+	// Feeds
+	SaveFeed(f *model.Feed) error
+	GetFeed(id int64) (*model.Feed, error)
+	GetFeedContext(ctx context.Context, id int64) (*model.Feed, error)
+	GetAllFeeds() ([]*model.Feed, error)
+	GetAllFeedsContext(ctx context.Context) ([]*model.Feed, error)
+	DeleteFeed(id int64) error
+	SetFeedRetention(feedID int64, p RetentionPolicy) error
+	GetFeedStats() ([]*FeedStats, error)
+	NewWriter() EntryWriter
+
+	// Entries
+	SaveEntries(feedID int64, entries []*model.Entry) (int, error)
+	SaveHistory(feedID int64, entries []*model.Entry) (int, error)
+	GetEntry(id int64) (*model.Entry, error)
+	GetEntries(opts QueryOptions) ([]*model.Entry, error)
+	GetDuplicates(id int64) ([]*model.Entry, error)
+	ListEntries(opts QueryOptions) (*EntryPage, error)
+	Search(query string, opts QueryOptions) ([]*SearchResult, error)
+	MarkEntriesRead(ids []int64, isRead bool) (int, error)
+	MarkReadWhere(opts QueryOptions, isRead bool) (int, error)
+	StarEntries(ids []int64, starred bool) (int, error)
+	Prune(opts PruneOptions) (*PruneResult, error)
+
+	// Tags
+	TagEntries(entryIDs []int64, tag string) (int, error)
+	UntagEntries(entryIDs []int64, tag string) (int, error)
+	GetTags() ([]*TagCount, error)
+	RenameTag(oldName, newName string) error
+	DeleteTag(name string) error
+
+	// Notes and highlights
+	SetNote(entryID int64, body string) (*model.Note, error)
+	DeleteNote(entryID int64) error
+	AddHighlight(entryID int64, text, comment string) (*model.Highlight, error)
+	DeleteHighlight(id int64) error
+	GetNotes(opts NoteOptions) ([]*model.Entry, error)
+
+	// Saved views
+	SaveView(name string, filter ViewFilter) (*View, error)
+	GetView(name string) (*View, error)
+	GetViews() ([]*ViewCount, error)
+	DeleteView(name string) error
+
+	// Read-later queue
+	QueueEntries(ids []int64, top bool) (int, error)
+	DequeueEntries(ids []int64) (int, error)
+	FinishQueueEntries(ids []int64) (read, removed int, err error)
+	MoveQueueEntry(entryID int64, position int) (int, error)
+	GetQueue(limit int) ([]*QueueItem, error)
+
+	// Statistics and history
+	GetStats() (*Stats, error)
+	GetReadingStats(since *int64) (*ReadingStats, error)
+
+	// Operations journal
+	BeginOperation(command string)
+	OperationID() int64
+	GetOperations(limit int) ([]*Operation, error)
+	Undo(id int64) (*UndoResult, error)
+
+	// Schema and maintenance
+	SchemaVersion() (int, error)
+	PendingMigrations() ([]Migration, error)
+	Migrate() ([]Migration, error)
+	Backup(path string) (*BackupResult, error)
+	Check() (*CheckResult, error)
+	Vacuum() (*VacuumResult, error)
+	Analyze() error
+}
+
+var _ Storage = (*Store)(nil)
@@ -1,44 +1,104 @@
-// Package store provides SQLite database operations for feed-cli.
+// Package store provides database operations for feed-cli on SQLite or
+// PostgreSQL.
 package store
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	_ "github.com/lib/pq"
+	"github.com/robertmeta/feed-cli/feed"
 	"github.com/robertmeta/feed-cli/model"
 	_ "modernc.org/sqlite"
 )
 
-// Store manages the SQLite database.
+// Store manages the database.
 type Store struct {
-	db *sql.DB
+	db     *conn
+	userID int64 // Current user; see SetUser
+
+	opMu      sync.Mutex
+	opCommand string // Recorded with the next operation
+	opID      int64  // Operation that further changes join; 0 before the first change
 }
 
 // QueryOptions specifies how to query entries.
 type QueryOptions struct {
-	Limit      int
-	Offset     int
-	UnreadOnly bool
-	Tag        string
-	SinceTime  *int64 // Unix timestamp
+	Limit       int
+	Offset      int
+	Cursor      string    // Resume after the last entry of a previous page
+	UnreadOnly  bool      // Shorthand for Read: ReadUnread
+	Read        ReadState // Read-state filter; empty means any
+	StarredOnly bool
+	ReadSince   *int64   // Marked read at or after (Unix timestamp)
+	OlderThanID int64    // Entries with a lower ID, i.e. stored earlier (0: no limit)
+	FeedIDs     []int64  // Entries from any of these feeds
+	Categories  []string // Entries from feeds in any of these categories
+	Tag         string
+	Tags        []string  // Additional tags; combined with Tag
+	AllTags     bool      // Require every tag instead of any of them
+	SinceTime   *int64    // Unix timestamp
+	After       *int64    // Published at or after (Unix timestamp)
+	Before      *int64    // Published before (Unix timestamp)
+	Sort        SortField // Defaults to SortPublished
+	Order       SortOrder // Defaults to SortDesc
+	Fields      []string  // Only load these fields (see EntryFields); empty loads all but content
+	WithContent bool      // Also load content, which is left out by default
+	Dedupe      bool      // Only the earliest stored matching entry of each duplicate group
 }
 
-// New creates a new Store with the given database path.
-// Use ":memory:" for an in-memory database (useful for testing).
+// tags returns Tag and Tags combined, normalized and without repeats.
+func (o QueryOptions) tags() []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range append([]string{o.Tag}, o.Tags...) {
+		if t, err := normalizeTag(t); err == nil && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// New opens the database at dbPath and applies any pending schema migrations.
+// Use ":memory:" for an in-memory database (useful for testing). A
+// postgres:// URL opens a PostgreSQL database instead of a SQLite file.
 func New(dbPath string) (*Store, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	store, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	return store, nil
+}
+
+// Open opens the database at dbPath without applying migrations.
+// It refuses databases whose schema is newer than this build supports.
+func Open(dbPath string) (*Store, error) {
+	d := dialectFor(dbPath)
+	db, err := sql.Open(d.driverName(), d.dataSource(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if dbPath == ":memory:" {
+		// Every connection would get its own, empty, database
+		db.SetMaxOpenConns(1)
+	}
 
-	store := &Store{db: db}
+	store := &Store{db: &conn{DB: db, d: d}, userID: defaultUserID}
 
-	// Initialize schema
-	if err := store.createSchema(); err != nil {
+	if _, err := store.checkVersion(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create schema: %w", err)
+		return nil, err
 	}
 
 	return store, nil
@@ -49,72 +109,18 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
-// createSchema creates the database tables and indexes.
-func (s *Store) createSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS feeds (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		url TEXT UNIQUE NOT NULL,
-		title TEXT,
-		category TEXT,
-		last_updated INTEGER,
-		etag TEXT,
-		last_modified TEXT
-	);
-
-	CREATE TABLE IF NOT EXISTS entries (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		feed_id INTEGER NOT NULL,
-		guid TEXT NOT NULL,
-		title TEXT,
-		link TEXT,
-		content TEXT,
-		published INTEGER NOT NULL,
-		is_read INTEGER DEFAULT 0,
-		FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE,
-		UNIQUE(feed_id, guid)
-	);
-
-	CREATE TABLE IF NOT EXISTS tags (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT UNIQUE NOT NULL
-	);
-
-	CREATE TABLE IF NOT EXISTS entry_tags (
-		entry_id INTEGER NOT NULL,
-		tag_id INTEGER NOT NULL,
-		PRIMARY KEY (entry_id, tag_id),
-		FOREIGN KEY (entry_id) REFERENCES entries(id) ON DELETE CASCADE,
-		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_entries_published ON entries(published DESC);
-	CREATE INDEX IF NOT EXISTS idx_entries_is_read ON entries(is_read);
-	CREATE INDEX IF NOT EXISTS idx_entries_feed_id ON entries(feed_id);
-	`
-
-	_, err := s.db.Exec(schema)
-	return err
-}
-
 // SaveFeed saves a feed to the database.
 // If the feed has an ID of 0, it will be inserted. Otherwise, it will be updated.
 func (s *Store) SaveFeed(f *model.Feed) error {
 	if f.ID == 0 {
 		// Insert
-		result, err := s.db.Exec(
-			"INSERT INTO feeds (url, title, category, etag, last_modified) VALUES (?, ?, ?, ?, ?)",
+		err := s.db.QueryRow(
+			"INSERT INTO feeds (url, title, category, etag, last_modified) VALUES (?, ?, ?, ?, ?) RETURNING id",
 			f.URL, f.Title, f.Category, f.ETag, f.LastModified,
-		)
+		).Scan(&f.ID)
 		if err != nil {
 			return fmt.Errorf("failed to insert feed: %w", err)
 		}
-
-		id, err := result.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get last insert ID: %w", err)
-		}
-		f.ID = id
 		return nil
 	}
 
@@ -126,13 +132,32 @@ func (s *Store) SaveFeed(f *model.Feed) error {
 	return err
 }
 
+// feedColumns is the column list scanned by scanFeed.
+const feedColumns = "id, url, title, category, last_updated, etag, last_modified, COALESCE(retain_count, 0), COALESCE(retain_days, 0)"
+
+// scanFeed scans a row selected with feedColumns (optionally followed by extra columns).
+func scanFeed(row rowScanner, extra ...interface{}) (*model.Feed, error) {
+	feed := &model.Feed{}
+	var lastUpdated sql.NullInt64
+	dest := []interface{}{&feed.ID, &feed.URL, &feed.Title, &feed.Category, &lastUpdated, &feed.ETag, &feed.LastModified, &feed.RetainCount, &feed.RetainDays}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	feed.LastUpdated = nullUnixToTime(lastUpdated)
+	return feed, nil
+}
+
 // GetFeed retrieves a feed by ID.
 func (s *Store) GetFeed(id int64) (*model.Feed, error) {
-	feed := &model.Feed{}
-	err := s.db.QueryRow(
-		"SELECT id, url, title, category, etag, last_modified FROM feeds WHERE id = ?",
+	return s.GetFeedContext(context.Background(), id)
+}
+
+// GetFeedContext is like GetFeed but honours ctx cancellation.
+func (s *Store) GetFeedContext(ctx context.Context, id int64) (*model.Feed, error) {
+	feed, err := scanFeed(s.db.QueryRowContext(ctx,
+		"SELECT "+feedColumns+" FROM feeds WHERE id = ?",
 		id,
-	).Scan(&feed.ID, &feed.URL, &feed.Title, &feed.Category, &feed.ETag, &feed.LastModified)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, errors.New("feed not found")
@@ -146,7 +171,12 @@ func (s *Store) GetFeed(id int64) (*model.Feed, error) {
 
 // GetAllFeeds retrieves all feeds.
 func (s *Store) GetAllFeeds() ([]*model.Feed, error) {
-	rows, err := s.db.Query("SELECT id, url, title, category, etag, last_modified FROM feeds")
+	return s.GetAllFeedsContext(context.Background())
+}
+
+// GetAllFeedsContext is like GetAllFeeds but honours ctx cancellation.
+func (s *Store) GetAllFeedsContext(ctx context.Context) ([]*model.Feed, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+feedColumns+" FROM feeds")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query feeds: %w", err)
 	}
@@ -154,8 +184,7 @@ func (s *Store) GetAllFeeds() ([]*model.Feed, error) {
 
 	var feeds []*model.Feed
 	for rows.Next() {
-		feed := &model.Feed{}
-		err := rows.Scan(&feed.ID, &feed.URL, &feed.Title, &feed.Category, &feed.ETag, &feed.LastModified)
+		feed, err := scanFeed(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan feed: %w", err)
 		}
@@ -165,50 +194,302 @@ func (s *Store) GetAllFeeds() ([]*model.Feed, error) {
 	return feeds, rows.Err()
 }
 
-// DeleteFeed deletes a feed by ID.
+// DeleteFeed deletes a feed by ID along with its entries. Entries starred,
+// annotated or queued by any user are kept.
 func (s *Store) DeleteFeed(id int64) error {
-	_, err := s.db.Exec("DELETE FROM feeds WHERE id = ?", id)
-	return err
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Foreign keys are not enforced, so remove dependent rows explicitly
+	deleted := "SELECT id FROM entries WHERE feed_id = ? AND id NOT IN (" + keptEntries + ")"
+	for _, table := range []string{"entry_tags", "entry_state", "entry_content"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE entry_id IN ("+deleted+")", id); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM entries WHERE id IN ("+deleted+")", id); err != nil {
+		return fmt.Errorf("failed to delete entries: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM entry_tombstones WHERE feed_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete tombstones: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM feeds WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete feed: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-// SaveEntry saves an entry to the database.
+// ErrEntryPruned is returned by SaveEntry for a new entry whose GUID was
+// pruned from its feed.
+var ErrEntryPruned = errors.New("entry was pruned")
+
+// SaveEntry saves an entry to the database. Its read state (IsRead, ReadAt)
+// is saved for the current user. Like SaveEntries, it does not bring back
+// pruned entries.
 func (s *Store) SaveEntry(e *model.Entry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sites, err := feedSiteNames(context.Background(), tx, e.FeedID)
+	if err != nil {
+		return err
+	}
+	link, title := feed.CanonicalLink(e.Link), titleKey(e.Title, sites)
 	if e.ID == 0 {
 		// Insert
-		result, err := s.db.Exec(
-			"INSERT INTO entries (feed_id, guid, title, link, content, published, is_read) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			e.FeedID, e.GUID, e.Title, e.Link, e.Content, e.Published.Unix(), boolToInt(e.IsRead),
-		)
+		var pruned bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM entry_tombstones WHERE feed_id = ? AND guid = ?)", e.FeedID, e.GUID).Scan(&pruned)
 		if err != nil {
-			return fmt.Errorf("failed to insert entry: %w", err)
+			return fmt.Errorf("failed to check tombstones: %w", err)
+		}
+		if pruned {
+			return fmt.Errorf("%w: %s", ErrEntryPruned, e.GUID)
 		}
 
-		id, err := result.LastInsertId()
+		err = tx.QueryRow(
+			"INSERT INTO entries (feed_id, guid, title, link, original_link, author, published, canonical_link, title_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id",
+			e.FeedID, e.GUID, e.Title, e.Link, e.OriginalLink, e.Author, e.Published.Unix(), link, title,
+		).Scan(&e.ID)
 		if err != nil {
-			return fmt.Errorf("failed to get last insert ID: %w", err)
+			return fmt.Errorf("failed to insert entry: %w", err)
+		}
+		if err := groupEntries(context.Background(), tx, []*model.Entry{e}); err != nil {
+			return err
+		}
+	} else {
+		// Update; the entry stays in its duplicate group
+		_, err := tx.Exec(
+			"UPDATE entries SET feed_id = ?, guid = ?, title = ?, link = ?, original_link = ?, author = ?, published = ?, canonical_link = ?, title_key = ? WHERE id = ?",
+			e.FeedID, e.GUID, e.Title, e.Link, e.OriginalLink, e.Author, e.Published.Unix(), link, title, e.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update entry: %w", err)
 		}
-		e.ID = id
-		return nil
 	}
 
-	// Update
-	_, err := s.db.Exec(
-		"UPDATE entries SET feed_id = ?, guid = ?, title = ?, link = ?, content = ?, published = ?, is_read = ? WHERE id = ?",
-		e.FeedID, e.GUID, e.Title, e.Link, e.Content, e.Published.Unix(), boolToInt(e.IsRead), e.ID,
+	// Kept even when empty, see insertEntries
+	_, err = tx.Exec(
+		"INSERT INTO entry_content (entry_id, content) VALUES (?, ?) ON CONFLICT (entry_id) DO UPDATE SET content = excluded.content",
+		e.ID, e.Content,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to save entry content: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO entry_state (user_id, entry_id, is_read, read_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, entry_id) DO UPDATE SET is_read = excluded.is_read, read_at = excluded.read_at`,
+		s.userID, e.ID, boolToInt(e.IsRead), timeToNullUnix(e.ReadAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save entry state: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-// GetEntry retrieves an entry by ID.
-func (s *Store) GetEntry(id int64) (*model.Entry, error) {
+// SaveEntries inserts a feed's entries in a single transaction using a prepared
+// statement. Entries whose GUID already exists for the feed, or was pruned, are
+// skipped. It returns the number of newly inserted entries; new entries get
+// their ID set.
+func (s *Store) SaveEntries(feedID int64, entries []*model.Entry) (int, error) {
+	return s.SaveEntriesContext(context.Background(), feedID, entries)
+}
+
+// SaveEntriesContext is like SaveEntries but rolls back if ctx is done before commit.
+func (s *Store) SaveEntriesContext(ctx context.Context, feedID int64, entries []*model.Entry) (int, error) {
+	return s.saveEntries(ctx, s.userID, feedID, entries)
+}
+
+// allUsers stands for every user where a user ID is expected; IDs start at 1.
+const allUsers int64 = 0
+
+// SaveHistory is like SaveEntries for entries that predate every user, such
+// as a feed's archive: entries with IsRead set are read for every user, not
+// only the current one.
+func (s *Store) SaveHistory(feedID int64, entries []*model.Entry) (int, error) {
+	return s.saveEntries(context.Background(), allUsers, feedID, entries)
+}
+
+// saveEntries inserts entries in one transaction, see insertEntries.
+func (s *Store) saveEntries(ctx context.Context, user, feedID int64, entries []*model.Entry) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err := insertEntries(ctx, tx, user, feedID, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit entries: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// SaveFetch records the result of fetching a feed in one transaction: new
+// entries are inserted as in SaveEntries and the feed's HTTP cache validators
+// (ETag, Last-Modified) and last_updated time are stored. Any recorded fetch
+// error is cleared.
+func (s *Store) SaveFetch(f *model.Feed, entries []*model.Entry) (int, error) {
+	return s.SaveFetchContext(context.Background(), f, entries)
+}
+
+// SaveFetchContext is like SaveFetch but rolls back if ctx is done before commit.
+func (s *Store) SaveFetchContext(ctx context.Context, f *model.Feed, entries []*model.Entry) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err := insertEntries(ctx, tx, s.userID, f.ID, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastUpdated interface{}
+	if f.LastUpdated != nil {
+		lastUpdated = f.LastUpdated.Unix()
+	}
+	// A successful fetch resets the feed's failure streak
+	_, err = tx.ExecContext(ctx,
+		"UPDATE feeds SET etag = ?, last_modified = ?, last_updated = ?, last_error = NULL, error_count = 0 WHERE id = ?",
+		f.ETag, f.LastModified, lastUpdated, f.ID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update feed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit entries: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// insertEntries inserts entries within tx using a prepared statement,
+// skipping GUIDs that already exist for the feed, and groups them with their
+// duplicates. Entries with IsRead set are marked read for user, or for every
+// user if user is allUsers.
+func insertEntries(ctx context.Context, tx *txn, user, feedID int64, entries []*model.Entry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	// Pruned entries leave a tombstone so they are not re-inserted as new
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO entries (feed_id, guid, title, link, author, published, canonical_link, title_key, original_link)
+		SELECT CAST(?1 AS BIGINT), CAST(?2 AS TEXT), CAST(?3 AS TEXT), CAST(?4 AS TEXT),
+			CAST(?5 AS TEXT), CAST(?6 AS BIGINT), CAST(?7 AS TEXT), CAST(?8 AS TEXT), CAST(?9 AS TEXT)
+		WHERE NOT EXISTS (SELECT 1 FROM entry_tombstones WHERE feed_id = ?1 AND guid = ?2)
+		ON CONFLICT(feed_id, guid) DO NOTHING
+		RETURNING id`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	// Every entry gets a content row, even an empty one: inserting it is what
+	// indexes the entry for search
+	contentStmt, err := tx.PrepareContext(ctx, "INSERT INTO entry_content (entry_id, content) VALUES (?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare content insert: %w", err)
+	}
+	defer contentStmt.Close()
+
+	sites, err := feedSiteNames(ctx, tx, feedID)
+	if err != nil {
+		return 0, err
+	}
+
+	markRead := "INSERT INTO entry_state (user_id, entry_id, is_read, read_at) SELECT id, CAST(? AS BIGINT), 1, CAST(? AS BIGINT) FROM users"
+	readFor := []interface{}{}
+	if user != allUsers {
+		markRead += " WHERE id = ?"
+		readFor = append(readFor, user)
+	}
+
+	stored := []*model.Entry{}
+	for _, e := range entries {
+		e.FeedID = feedID
+		link, title := feed.CanonicalLink(e.Link), titleKey(e.Title, sites)
+		err := stmt.QueryRowContext(ctx, e.FeedID, e.GUID, e.Title, e.Link, e.Author, e.Published.Unix(), link, title, e.OriginalLink).Scan(&e.ID)
+		if err == sql.ErrNoRows {
+			continue // Duplicate or pruned GUID
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert entry %s: %w", e.GUID, err)
+		}
+		if _, err := contentStmt.ExecContext(ctx, e.ID, e.Content); err != nil {
+			return 0, fmt.Errorf("failed to insert content of entry %s: %w", e.GUID, err)
+		}
+		if e.IsRead {
+			_, err := tx.ExecContext(ctx, markRead, append([]interface{}{e.ID, timeToNullUnix(e.ReadAt)}, readFor...)...)
+			if err != nil {
+				return 0, fmt.Errorf("failed to mark entry %s read: %w", e.GUID, err)
+			}
+		}
+		stored = append(stored, e)
+	}
+
+	if err := groupEntries(ctx, tx, stored); err != nil {
+		return 0, err
+	}
+	return len(stored), nil
+}
+
+// entryColumns is the column list scanned by scanEntry. Queries selecting it
+// join the current user's state with stateJoin. Content lives in
+// entry_content and is loaded separately with loadContent.
+const entryColumns = "entries.id, entries.feed_id, entries.guid, COALESCE(entries.title, ''), entries.link, entries.original_link, COALESCE(entries.author, ''), entries.published, COALESCE(st.is_read, 0), st.read_at, st.starred_at, COALESCE(entries.group_id, 0)"
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntry scans a row selected with entryColumns (optionally followed by extra columns).
+func scanEntry(row rowScanner, extra ...interface{}) (*model.Entry, error) {
 	entry := &model.Entry{}
 	var publishedUnix int64
 	var isReadInt int
+	var readAt, starredAt sql.NullInt64
 
-	err := s.db.QueryRow(
-		"SELECT id, feed_id, guid, title, link, content, published, is_read FROM entries WHERE id = ?",
+	dest := []interface{}{&entry.ID, &entry.FeedID, &entry.GUID, &entry.Title, &entry.Link, &entry.OriginalLink, &entry.Author, &publishedUnix, &isReadInt, &readAt, &starredAt, &entry.GroupID}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	entry.Published = unixToTime(publishedUnix)
+	entry.IsRead = intToBool(isReadInt)
+	entry.ReadAt = nullUnixToTime(readAt)
+	entry.StarredAt = nullUnixToTime(starredAt)
+	return entry, nil
+}
+
+// GetEntry retrieves an entry by ID, with its content and the current user's
+// tags, note and highlights.
+func (s *Store) GetEntry(id int64) (*model.Entry, error) {
+	entry, err := scanEntry(s.db.QueryRow(
+		"SELECT "+entryColumns+" FROM entries"+s.stateJoin()+" WHERE entries.id = ?",
 		id,
-	).Scan(&entry.ID, &entry.FeedID, &entry.GUID, &entry.Title, &entry.Link, &entry.Content, &publishedUnix, &isReadInt)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, errors.New("entry not found")
@@ -217,40 +498,63 @@ func (s *Store) GetEntry(id int64) (*model.Entry, error) {
 		return nil, fmt.Errorf("failed to get entry: %w", err)
 	}
 
-	entry.Published = unixToTime(publishedUnix)
-	entry.IsRead = intToBool(isReadInt)
+	if err := s.loadTags([]*model.Entry{entry}); err != nil {
+		return nil, err
+	}
+	if err := s.loadContent([]*model.Entry{entry}); err != nil {
+		return nil, err
+	}
+	if err := s.loadAnnotations([]*model.Entry{entry}); err != nil {
+		return nil, err
+	}
 
 	return entry, nil
 }
 
-// GetEntries retrieves entries with optional filtering, pagination.
-func (s *Store) GetEntries(opts QueryOptions) ([]*model.Entry, error) {
-	query := "SELECT id, feed_id, guid, title, link, content, published, is_read FROM entries WHERE 1=1"
-	args := []interface{}{}
-
-	// Apply filters
-	if opts.UnreadOnly {
-		query += " AND is_read = 0"
+// HasEntry reports whether an entry with the given GUID already exists for a feed.
+func (s *Store) HasEntry(feedID int64, guid string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM entries WHERE feed_id = ? AND guid = ?",
+		feedID, guid,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check entry: %w", err)
 	}
 
-	if opts.SinceTime != nil {
-		query += " AND published >= ?"
-		args = append(args, *opts.SinceTime)
+	return count > 0, nil
+}
+
+// GetEntries retrieves entries with optional filtering, pagination. Only the
+// fields in opts.Fields are read; content only with opts.WithContent.
+func (s *Store) GetEntries(opts QueryOptions) ([]*model.Entry, error) {
+	orderBy, err := opts.orderBy()
+	if err != nil {
+		return nil, err
 	}
 
-	// Order by published date (newest first)
-	query += " ORDER BY published DESC"
+	if err := opts.validateFields(); err != nil {
+		return nil, err
+	}
+	columns, scan, err := opts.projection()
+	if err != nil {
+		return nil, err
+	}
 
-	// Apply pagination
-	if opts.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, opts.Limit)
+	where, args, err := opts.listFilter(s.userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if opts.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, opts.Offset)
+	cond, cursorArgs, err := opts.cursorFilter()
+	if err != nil {
+		return nil, err
 	}
+	where += cond
+	args = append(args, cursorArgs...)
+
+	query := "SELECT " + columns + " FROM entries" + s.stateJoin() + " WHERE 1=1" + where + " ORDER BY " + orderBy
+	query, args = opts.paginate(query, args)
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -260,29 +564,105 @@ func (s *Store) GetEntries(opts QueryOptions) ([]*model.Entry, error) {
 
 	var entries []*model.Entry
 	for rows.Next() {
-		entry := &model.Entry{}
-		var publishedUnix int64
-		var isReadInt int
-
-		err := rows.Scan(&entry.ID, &entry.FeedID, &entry.GUID, &entry.Title, &entry.Link, &entry.Content, &publishedUnix, &isReadInt)
+		entry, err := scan(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan entry: %w", err)
 		}
-
-		entry.Published = unixToTime(publishedUnix)
-		entry.IsRead = intToBool(isReadInt)
 		entries = append(entries, entry)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query entries: %w", err)
+	}
+
+	if opts.wants("tags") {
+		if err := s.loadTags(entries); err != nil {
+			return nil, err
+		}
+	}
+	if opts.wants("content") {
+		if err := s.loadContent(entries); err != nil {
+			return nil, err
+		}
+	}
 
-	return entries, rows.Err()
+	return entries, nil
 }
 
 // MarkEntryRead marks an entry as read or unread.
 func (s *Store) MarkEntryRead(id int64, isRead bool) error {
-	_, err := s.db.Exec("UPDATE entries SET is_read = ? WHERE id = ?", boolToInt(isRead), id)
+	_, err := s.MarkEntriesRead([]int64{id}, isRead)
 	return err
 }
 
+// MarkEntriesRead marks entries as read or unread for the current user and
+// returns how many changed. Marking read records read_at; an entry that is already read keeps
+// its original read_at. Marking unread clears it. Changes are journaled.
+// Marking read also marks the entries' duplicates read; see markRead.
+func (s *Store) MarkEntriesRead(ids []int64, isRead bool) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return s.markRead(" AND entries.id IN ("+placeholders(len(ids))+")", args, isRead)
+}
+
+// MarkReadWhere marks every entry matching the filters in opts as read or
+// unread for the current user and returns how many changed. Pagination and
+// sorting are ignored; empty options match all entries. Changes are journaled.
+func (s *Store) MarkReadWhere(opts QueryOptions, isRead bool) (int, error) {
+	where, args, err := opts.filter(s.userID)
+	if err != nil {
+		return 0, err
+	}
+	return s.markRead(where, args, isRead)
+}
+
+// markRead updates the read state of entries matching where, skipping
+// entries already in that state so the count is exact. Marking read covers
+// every entry in the duplicate groups of the matching ones, and counts them.
+func (s *Store) markRead(where string, args []interface{}, isRead bool) (int, error) {
+	return s.journaled(func(tx *txn) (int, int64, error) {
+		return s.markReadTx(tx, where, args, isRead)
+	})
+}
+
+// markReadTx is markRead within tx, returning the operation the changes were
+// journaled under. s.opMu must be held.
+func (s *Store) markReadTx(tx *txn, where string, args []interface{}, isRead bool) (int, int64, error) {
+	if isRead {
+		where = " AND COALESCE(entries.group_id, entries.id) IN (SELECT COALESCE(entries.group_id, entries.id) FROM entries" + s.stateJoin() + " WHERE 1=1" + where + ")"
+		now := time.Now().Unix()
+		return s.changeEntriesTx(tx, ActionRead, "read_at", now, "is_read = 1, read_at = ?", "COALESCE(st.is_read, 0) = 0"+where, args)
+	}
+	return s.changeEntriesTx(tx, ActionUnread, "read_at", nil, "is_read = 0, read_at = NULL", "st.is_read = 1"+where, args)
+}
+
+// StarEntries stars or unstars entries for the current user and returns how
+// many changed.
+// Starring an already starred entry keeps its original starred_at. Changes
+// are journaled.
+func (s *Store) StarEntries(ids []int64, starred bool) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	where := " AND entries.id IN (" + placeholders(len(ids)) + ")"
+
+	if starred {
+		now := time.Now().Unix()
+		return s.changeEntries(ActionStar, "starred_at", now, "starred_at = ?", "st.starred_at IS NULL"+where, args)
+	}
+	return s.changeEntries(ActionUnstar, "starred_at", nil, "starred_at = NULL", "st.starred_at IS NOT NULL"+where, args)
+}
+
 // Helper functions for boolean<->int conversion (SQLite doesn't have BOOLEAN type)
 func boolToInt(b bool) int {
 	if b {
@@ -299,3 +679,20 @@ func intToBool(i int) bool {
 func unixToTime(unix int64) time.Time {
 	return time.Unix(unix, 0)
 }
+
+// Helper to convert a nullable Unix timestamp to *time.Time
+func nullUnixToTime(unix sql.NullInt64) *time.Time {
+	if !unix.Valid {
+		return nil
+	}
+	t := unixToTime(unix.Int64)
+	return &t
+}
+
+// Helper to convert *time.Time to a nullable Unix timestamp
+func timeToNullUnix(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
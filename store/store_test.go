@@ -1,6 +1,7 @@
 package store
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -11,14 +12,45 @@ import (
 
 func TestNewStore(t *testing.T) {
 	// Test creating a new in-memory database
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	require.NotNil(t, s)
 	defer s.Close()
 }
 
+func TestOpen_WaitsForLockedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	writer, err := New(path)
+	require.NoError(t, err)
+	defer writer.Close()
+	other, err := Open(path)
+	require.NoError(t, err)
+	defer other.Close()
+
+	// Another connection holds the write lock for a while
+	tx, err := writer.db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO feeds (url) VALUES ('https://a.example.com/rss')")
+	require.NoError(t, err)
+	committed := make(chan error, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		committed <- tx.Commit()
+	}()
+
+	// Waits for the lock instead of failing with SQLITE_BUSY
+	start := time.Now()
+	require.NoError(t, other.SaveFeed(&model.Feed{URL: "https://b.example.com/rss"}))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	require.NoError(t, <-committed)
+
+	var feeds int
+	require.NoError(t, other.db.QueryRow("SELECT COUNT(*) FROM feeds").Scan(&feeds))
+	assert.Equal(t, 2, feeds)
+}
+
 func TestStore_SaveAndGetFeed(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -42,7 +74,7 @@ func TestStore_SaveAndGetFeed(t *testing.T) {
 }
 
 func TestStore_GetAllFeeds(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -65,7 +97,7 @@ func TestStore_GetAllFeeds(t *testing.T) {
 }
 
 func TestStore_DeleteFeed(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -87,7 +119,7 @@ func TestStore_DeleteFeed(t *testing.T) {
 }
 
 func TestStore_SaveAndGetEntry(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -124,8 +156,31 @@ func TestStore_SaveAndGetEntry(t *testing.T) {
 	assert.Equal(t, entry.IsRead, got.IsRead)
 }
 
+func TestStore_SaveEntriesKeepsOriginalLink(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	entry := &model.Entry{GUID: "a", Link: "https://example.com/a", OriginalLink: "/a?utm_source=rss", Published: time.Now()}
+	_, err = s.SaveEntries(feed.ID, []*model.Entry{entry})
+	require.NoError(t, err)
+
+	got, err := s.GetEntry(entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", got.Link)
+	assert.Equal(t, "/a?utm_source=rss", got.OriginalLink)
+
+	entries, err := s.GetEntries(QueryOptions{Fields: []string{"original_link"}})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/a?utm_source=rss", entries[0].OriginalLink)
+}
+
 func TestStore_GetEntries_Pagination(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -181,7 +236,7 @@ func TestStore_GetEntries_Pagination(t *testing.T) {
 }
 
 func TestStore_GetEntries_UnreadFilter(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -221,7 +276,7 @@ func TestStore_GetEntries_UnreadFilter(t *testing.T) {
 }
 
 func TestStore_MarkEntryRead(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -259,7 +314,7 @@ func TestStore_MarkEntryRead(t *testing.T) {
 }
 
 func TestStore_UniqueConstraints(t *testing.T) {
-	s, err := New(":memory:")
+	s, err := openTestStore(t)
 	require.NoError(t, err)
 	defer s.Close()
 
@@ -299,3 +354,85 @@ func TestStore_UniqueConstraints(t *testing.T) {
 	err = s.SaveEntry(duplicateEntry)
 	assert.Error(t, err, "Should error on duplicate GUID in same feed")
 }
+
+func TestStore_HasEntry(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	err = s.SaveFeed(feed)
+	require.NoError(t, err)
+
+	exists, err := s.HasEntry(feed.ID, "guid-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	err = s.SaveEntry(&model.Entry{FeedID: feed.ID, GUID: "guid-1", Published: time.Now()})
+	require.NoError(t, err)
+
+	exists, err = s.HasEntry(feed.ID, "guid-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Same GUID in another feed is not a duplicate
+	exists, err = s.HasEntry(feed.ID+1, "guid-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestStore_StarEntries(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	n, err := s.StarEntries([]int64{entries[0].ID, entries[2].ID}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	require.True(t, entry.IsStarred())
+	starredAt := *entry.StarredAt
+
+	// Starring again keeps the original timestamp
+	n, err = s.StarEntries([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, starredAt, *entry.StarredAt)
+
+	starred, err := s.GetEntries(QueryOptions{StarredOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, entryGUIDs(starred))
+
+	n, err = s.StarEntries([]int64{entries[2].ID}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	starred, err = s.GetEntries(QueryOptions{StarredOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, entryGUIDs(starred))
+}
+
+func TestStore_DeleteFeedKeepsStarred(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.StarEntries([]int64{entries[1].ID}, true)
+	require.NoError(t, err)
+	_, err = s.TagEntries([]int64{entries[0].ID, entries[1].ID}, "go")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteFeed(entries[0].FeedID))
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, entryGUIDs(remaining))
+	assert.Equal(t, []string{"go"}, remaining[0].Tags)
+
+	tags, err := s.GetTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, 1, tags[0].Count)
+}
@@ -0,0 +1,264 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// TagCount is a tag with the number of entries it is applied to.
+type TagCount struct {
+	model.Tag
+	Count int `json:"count"`
+}
+
+// normalizeTag trims a tag name and rejects empty names.
+func normalizeTag(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("tag name is required")
+	}
+	return name, nil
+}
+
+// TagEntries applies one of the current user's tags to entries, creating the
+// tag if needed.
+// It returns the number of entries that were newly tagged.
+func (s *Store) TagEntries(entryIDs []int64, tag string) (int, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO tags (user_id, name) VALUES (?, ?) ON CONFLICT(user_id, name) DO NOTHING", s.userID, tag); err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	var tagID int64
+	if err := tx.QueryRow("SELECT id FROM tags WHERE user_id = ? AND name = ?", s.userID, tag).Scan(&tagID); err != nil {
+		return 0, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	tagged := 0
+	for _, id := range entryIDs {
+		// Only tag entries that exist
+		result, err := tx.Exec(
+			"INSERT INTO entry_tags (entry_id, tag_id) SELECT id, CAST(? AS BIGINT) FROM entries WHERE id = ? ON CONFLICT DO NOTHING",
+			tagID, id,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to tag entry %d: %w", id, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		tagged += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return tagged, nil
+}
+
+// UntagEntries removes one of the current user's tags from entries and returns how many were untagged.
+func (s *Store) UntagEntries(entryIDs []int64, tag string) (int, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return 0, err
+	}
+	if len(entryIDs) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{s.userID, tag}
+	for _, id := range entryIDs {
+		args = append(args, id)
+	}
+
+	result, err := s.db.Exec(
+		"DELETE FROM entry_tags WHERE tag_id = (SELECT id FROM tags WHERE user_id = ? AND name = ?) AND entry_id IN ("+placeholders(len(entryIDs))+")",
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to untag entries: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// GetTags returns the current user's tags with the number of entries each is
+// applied to.
+func (s *Store) GetTags() ([]*TagCount, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.name, COUNT(et.entry_id)
+		FROM tags t LEFT JOIN entry_tags et ON et.tag_id = t.id
+		WHERE t.user_id = ?
+		GROUP BY t.id
+		ORDER BY t.name`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*TagCount{}
+	for rows.Next() {
+		tag := &TagCount{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// RenameTag renames one of the current user's tags. It fails if the tag does not exist or the new name is taken.
+func (s *Store) RenameTag(oldName, newName string) error {
+	oldName, err := normalizeTag(oldName)
+	if err != nil {
+		return err
+	}
+	newName, err = normalizeTag(newName)
+	if err != nil {
+		return err
+	}
+
+	var existing int64
+	err = s.db.QueryRow("SELECT id FROM tags WHERE user_id = ? AND name = ?", s.userID, newName).Scan(&existing)
+	if err == nil {
+		return fmt.Errorf("tag %q already exists", newName)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check tag: %w", err)
+	}
+
+	result, err := s.db.Exec("UPDATE tags SET name = ? WHERE user_id = ? AND name = ?", newName, s.userID, oldName)
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("tag not found")
+	}
+	return nil
+}
+
+// DeleteTag deletes one of the current user's tags and removes it from all
+// entries.
+func (s *Store) DeleteTag(name string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM entry_tags WHERE tag_id = (SELECT id FROM tags WHERE user_id = ? AND name = ?)", s.userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to untag entries: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM tags WHERE user_id = ? AND name = ?", s.userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("tag not found")
+	}
+
+	return tx.Commit()
+}
+
+// tagFilter returns the SQL condition and arguments restricting entries to
+// user's given tags: entries with any of them, or with all of them when
+// matchAll is set. tags must be distinct and normalized, see
+// QueryOptions.tags.
+func tagFilter(user int64, tags []string, matchAll bool) (string, []interface{}) {
+	args := []interface{}{user}
+	for _, t := range tags {
+		args = append(args, t)
+	}
+
+	cond := " AND entries.id IN (SELECT et.entry_id FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE t.user_id = ? AND t.name IN (" + placeholders(len(tags)) + ")"
+	if matchAll {
+		cond += " GROUP BY et.entry_id HAVING COUNT(DISTINCT t.id) = ?"
+		args = append(args, len(tags))
+	}
+	return cond + ")", args
+}
+
+// loadTagsBatch bounds the number of IDs per query to stay below SQLite's
+// host parameter limit.
+const loadTagsBatch = 500
+
+// loadTags fills in the current user's Tags for the given entries.
+func (s *Store) loadTags(entries []*model.Entry) error {
+	for start := 0; start < len(entries); start += loadTagsBatch {
+		end := start + loadTagsBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := s.loadTagsChunk(entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) loadTagsChunk(entries []*model.Entry) error {
+	byID := make(map[int64]*model.Entry, len(entries))
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, s.userID)
+	for _, e := range entries {
+		byID[e.ID] = e
+		args = append(args, e.ID)
+	}
+
+	rows, err := s.db.Query(
+		"SELECT et.entry_id, t.name FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE t.user_id = ? AND et.entry_id IN ("+placeholders(len(entries))+") ORDER BY t.name",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query entry tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID int64
+		var name string
+		if err := rows.Scan(&entryID, &name); err != nil {
+			return fmt.Errorf("failed to scan entry tag: %w", err)
+		}
+		if e, ok := byID[entryID]; ok {
+			e.Tags = append(e.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// placeholders returns n comma-separated "?" placeholders.
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
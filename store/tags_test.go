@@ -0,0 +1,134 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTagStore creates a store with three entries, newest first.
+func newTagStore(t *testing.T) (*Store, []*model.Entry) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	now := time.Now()
+	entries := []*model.Entry{}
+	for i, guid := range []string{"a", "b", "c"} {
+		e := &model.Entry{FeedID: feed.ID, GUID: guid, Title: guid, Published: now.Add(-time.Duration(i) * time.Hour)}
+		require.NoError(t, s.SaveEntry(e))
+		entries = append(entries, e)
+	}
+
+	return s, entries
+}
+
+func entryGUIDs(entries []*model.Entry) []string {
+	guids := []string{}
+	for _, e := range entries {
+		guids = append(guids, e.GUID)
+	}
+	return guids
+}
+
+func TestTags_TagAndUntag(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	n, err := s.TagEntries([]int64{entries[0].ID, entries[1].ID, 9999}, " go ")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "Missing entries are skipped")
+
+	// Tagging again is a no-op
+	n, err = s.TagEntries([]int64{entries[0].ID}, "go")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = s.TagEntries([]int64{entries[0].ID}, "")
+	assert.Error(t, err)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go"}, entry.Tags)
+
+	n, err = s.UntagEntries([]int64{entries[0].ID}, "go")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	entry, err = s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Tags)
+}
+
+func TestTags_FilterAnyAndAll(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.TagEntries([]int64{entries[0].ID, entries[1].ID}, "go")
+	require.NoError(t, err)
+	_, err = s.TagEntries([]int64{entries[1].ID, entries[2].ID}, "rust")
+	require.NoError(t, err)
+
+	result, err := s.GetEntries(QueryOptions{Tag: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, entryGUIDs(result))
+	assert.Equal(t, []string{"go", "rust"}, result[1].Tags)
+
+	result, err = s.GetEntries(QueryOptions{Tags: []string{"go", "rust"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, entryGUIDs(result))
+
+	result, err = s.GetEntries(QueryOptions{Tags: []string{"go", "rust"}, AllTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, entryGUIDs(result))
+
+	// Repeated tags count once
+	result, err = s.GetEntries(QueryOptions{Tag: "go", Tags: []string{"go", " go "}, AllTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, entryGUIDs(result))
+
+	result, err = s.GetEntries(QueryOptions{Tag: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestTags_ListRenameDelete(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	_, err := s.TagEntries([]int64{entries[0].ID, entries[1].ID}, "go")
+	require.NoError(t, err)
+	_, err = s.TagEntries([]int64{entries[2].ID}, "rust")
+	require.NoError(t, err)
+
+	tags, err := s.GetTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "go", tags[0].Name)
+	assert.Equal(t, 2, tags[0].Count)
+
+	assert.Error(t, s.RenameTag("go", "rust"), "Target name is taken")
+	assert.Error(t, s.RenameTag("missing", "other"))
+	require.NoError(t, s.RenameTag("go", "golang"))
+
+	result, err := s.GetEntries(QueryOptions{Tag: "golang"})
+	require.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	require.NoError(t, s.DeleteTag("golang"))
+	assert.Error(t, s.DeleteTag("golang"))
+
+	tags, err = s.GetTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "rust", tags[0].Name)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Tags)
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUser owns the read, star and tag state of databases created before
+// users existed, and is used when no user is selected.
+const DefaultUser = "default"
+
+// defaultUserID is the ID of DefaultUser, created by the users migration.
+const defaultUserID = 1
+
+// User is someone with their own read, star and tag state. Feeds and entries
+// are shared by all users.
+type User struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrUserNotFound is returned by SetUser for a name AddUser never created.
+var ErrUserNotFound = errors.New("user not found")
+
+// AddUser creates a user with their own, empty, read, star and tag state.
+// It does not change the current user.
+func (s *Store) AddUser(name string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("user name is required")
+	}
+
+	user := &User{Name: name, CreatedAt: unixToTime(time.Now().Unix())}
+	result, err := s.db.Exec("INSERT INTO users (name, created_at) VALUES (?, ?) ON CONFLICT(name) DO NOTHING", name, user.CreatedAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("user %s already exists", name)
+	}
+	if err := s.db.QueryRow("SELECT id FROM users WHERE name = ?", name).Scan(&user.ID); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// SetUser makes the existing user name the current user; an empty name
+// selects DefaultUser. Reads, stars, tags, statistics and the operations
+// journal are scoped to the current user from then on.
+func (s *Store) SetUser(name string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultUser
+	}
+
+	user := &User{Name: name}
+	var createdAt int64
+	err := s.db.QueryRow("SELECT id, created_at FROM users WHERE name = ?", name).Scan(&user.ID, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.CreatedAt = unixToTime(createdAt)
+
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	s.userID = user.ID
+	s.opID = 0 // Operations belong to one user
+	return user, nil
+}
+
+// GetUsers returns all users in the order they were created.
+func (s *Store) GetUsers() ([]*User, error) {
+	rows, err := s.db.Query("SELECT id, name, created_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		user := &User{}
+		var createdAt int64
+		if err := rows.Scan(&user.ID, &user.Name, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user.CreatedAt = unixToTime(createdAt)
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// stateJoin joins the current user's entry_state row (aliased st) to
+// entries; entries the user never touched get NULLs, i.e. unread and not
+// starred.
+func (s *Store) stateJoin() string {
+	return stateJoin(s.userID)
+}
+
+// stateJoin joins user's entry_state row to entries. The user ID is a
+// trusted integer, so it is inlined rather than bound to keep the join out of
+// every query's argument list.
+func stateJoin(user int64) string {
+	return " LEFT JOIN entry_state AS st ON st.entry_id = entries.id AND st.user_id = " + strconv.FormatInt(user, 10)
+}
+
+// keptEntries selects entries starred, annotated or queued by any user.
+// Shared cleanup such as pruning and feed removal keeps them.
+const keptEntries = `SELECT entry_id FROM entry_state WHERE starred_at IS NOT NULL
+	UNION SELECT entry_id FROM notes
+	UNION SELECT entry_id FROM highlights
+	UNION SELECT entry_id FROM queue_entries`
@@ -0,0 +1,264 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addUsers creates users for a test to switch between.
+func addUsers(t *testing.T, s *Store, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		_, err := s.AddUser(name)
+		require.NoError(t, err)
+	}
+}
+
+func TestUsers_AddSetAndList(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	users, err := s.GetUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, DefaultUser, users[0].Name)
+
+	_, err = s.SetUser("alice")
+	assert.ErrorIs(t, err, ErrUserNotFound, "Unknown users are not created")
+
+	added, err := s.AddUser(" alice ")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", added.Name)
+	assert.NotZero(t, added.ID)
+
+	_, err = s.AddUser("alice")
+	assert.Error(t, err, "Names are unique")
+	_, err = s.AddUser(" ")
+	assert.Error(t, err)
+
+	alice, err := s.SetUser(" alice ")
+	require.NoError(t, err)
+	assert.Equal(t, added.ID, alice.ID)
+	assert.Equal(t, "alice", alice.Name)
+
+	def, err := s.SetUser("")
+	require.NoError(t, err)
+	assert.Equal(t, users[0].ID, def.ID)
+
+	users, err = s.GetUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+}
+
+func TestUsers_SeparateState(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	addUsers(t, s, "alice", "bob")
+	_, err := s.SetUser("alice")
+	require.NoError(t, err)
+	_, err = s.MarkEntriesRead([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+	_, err = s.StarEntries([]int64{entries[1].ID}, true)
+	require.NoError(t, err)
+	_, err = s.TagEntries([]int64{entries[2].ID}, "go")
+	require.NoError(t, err)
+
+	unread, err := s.GetEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, entryGUIDs(unread))
+
+	_, err = s.SetUser("bob")
+	require.NoError(t, err)
+
+	unread, err = s.GetEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, entryGUIDs(unread), "Bob has not read anything")
+
+	entry, err := s.GetEntry(entries[1].ID)
+	require.NoError(t, err)
+	assert.False(t, entry.IsStarred())
+
+	entry, err = s.GetEntry(entries[2].ID)
+	require.NoError(t, err)
+	assert.Empty(t, entry.Tags)
+
+	tags, err := s.GetTags()
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	// Bob can use the same tag name independently
+	_, err = s.TagEntries([]int64{entries[0].ID}, "go")
+	require.NoError(t, err)
+	tagged, err := s.GetEntries(QueryOptions{Tag: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, entryGUIDs(tagged))
+
+	stats, err := s.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Unread)
+	assert.Zero(t, stats.Starred)
+	assert.Equal(t, 1, stats.Tags)
+}
+
+func TestUsers_OperationsAreScoped(t *testing.T) {
+	s, entries := newTagStore(t)
+	defer s.Close()
+
+	addUsers(t, s, "alice", "bob")
+	_, err := s.SetUser("alice")
+	require.NoError(t, err)
+	s.BeginOperation("read a")
+	_, err = s.MarkEntriesRead([]int64{entries[0].ID}, true)
+	require.NoError(t, err)
+	aliceOp := s.OperationID()
+
+	_, err = s.SetUser("bob")
+	require.NoError(t, err)
+	assert.Zero(t, s.OperationID(), "Switching users ends the operation")
+
+	ops, err := s.GetOperations(10)
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+
+	_, err = s.Undo(0)
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+	_, err = s.Undo(aliceOp)
+	assert.Error(t, err, "Bob cannot undo Alice's operation")
+
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	result, err := s.Undo(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Reverted)
+
+	entry, err := s.GetEntry(entries[0].ID)
+	require.NoError(t, err)
+	assert.False(t, entry.IsRead)
+}
+
+func TestUsers_PruneKeepsEntriesStarredByAnyone(t *testing.T) {
+	s, _, entries := newPruneStore(t)
+	defer s.Close()
+
+	addUsers(t, s, "alice")
+	_, err := s.SetUser("alice")
+	require.NoError(t, err)
+	_, err = s.StarEntries([]int64{entries[4].ID}, true)
+	require.NoError(t, err)
+
+	// Prune runs as the default user, who has read everything
+	_, err = s.SetUser("")
+	require.NoError(t, err)
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}, Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Deleted)
+
+	remaining, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e0", "e4"}, entryGUIDs(remaining))
+}
+
+func TestUsers_HistoryIsReadForEveryone(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	addUsers(t, s, "alice")
+	f := &model.Feed{URL: "https://example.com/rss"}
+	require.NoError(t, s.SaveFeed(f))
+
+	now := time.Now()
+	history := []*model.Entry{
+		{GUID: "old", Title: "Old", Published: now.Add(-48 * time.Hour), IsRead: true},
+		{GUID: "older", Title: "Older", Published: now.Add(-72 * time.Hour), IsRead: true},
+	}
+	n, err := s.SaveHistory(f.ID, history)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = s.SaveEntries(f.ID, []*model.Entry{{GUID: "new", Title: "New", Published: now, IsRead: true}})
+	require.NoError(t, err)
+
+	// Entries saved as the default user are read only for them
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	unread, err := s.GetEntries(QueryOptions{Read: ReadUnread})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new"}, entryGUIDs(unread))
+
+	// So history read by everyone can be pruned
+	result, err := s.Prune(PruneOptions{Default: RetentionPolicy{Keep: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+}
+
+func TestMigrate_SingleUserDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "single.db")
+
+	// A database from before users existed
+	all := migrations
+	migrations = all[:8]
+	s, err := New(path)
+	migrations = all
+	require.NoError(t, err)
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+	now := time.Now().Unix()
+	_, err = s.db.Exec(`
+		INSERT INTO entries (feed_id, guid, title, link, content, author, published, is_read, read_at, starred_at)
+		VALUES (?1, 'a', 'a', '', '<p>Kept body</p>', '', ?2, 1, ?2, NULL),
+		       (?1, 'b', 'b', '', '', '', ?2, 0, NULL, ?2),
+		       (?1, 'c', 'c', '', '', '', ?2, 0, NULL, NULL);
+		INSERT INTO tags (name) VALUES ('go');
+		INSERT INTO entry_tags (entry_id, tag_id) SELECT id, 1 FROM entries WHERE guid = 'c';`,
+		feed.ID, now)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = New(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	unread, err := s.GetEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b", "c"}, entryGUIDs(unread))
+
+	starred, err := s.GetEntries(QueryOptions{StarredOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, entryGUIDs(starred))
+
+	tagged, err := s.GetEntries(QueryOptions{Tag: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, entryGUIDs(tagged))
+
+	history, err := s.GetEntries(QueryOptions{Sort: SortRead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, entryGUIDs(history))
+
+	// Content moved to its own table and is still searchable
+	entry, err := s.GetEntry(history[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Kept body</p>", entry.Content)
+	results, err := s.Search("body", QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Other users start fresh
+	addUsers(t, s, "alice")
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	unread, err = s.GetEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Len(t, unread, 3)
+
+	check, err := s.Check()
+	require.NoError(t, err)
+	assert.True(t, check.OK, "%+v", check)
+}
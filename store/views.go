@@ -0,0 +1,211 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// View is a named list query saved by the current user, like a smart folder.
+type View struct {
+	Name      string     `json:"name"`
+	Filter    ViewFilter `json:"filter"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ViewCount is a view with the number of entries it matches right now.
+type ViewCount struct {
+	*View
+	Count  int `json:"count"`  // Matching entries, ignoring the view's limit
+	Unread int `json:"unread"` // Of those, unread
+}
+
+// ViewFilter is what a view saves: the filters, sort and limit of a list
+// query. Since is kept as a duration so the view moves with time.
+type ViewFilter struct {
+	Unread     bool      `json:"unread,omitempty"`
+	Read       ReadState `json:"read,omitempty"`
+	Starred    bool      `json:"starred,omitempty"`
+	Since      string    `json:"since,omitempty"`  // Duration, e.g. 7d
+	After      string    `json:"after,omitempty"`  // Date, see ParseDate
+	Before     string    `json:"before,omitempty"` // Date, see ParseDate
+	FeedIDs    []int64   `json:"feed_ids,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	AllTags    bool      `json:"all_tags,omitempty"`
+	Dedupe     bool      `json:"dedupe,omitempty"`
+	Sort       SortField `json:"sort,omitempty"`
+	Order      SortOrder `json:"order,omitempty"`
+	Limit      int       `json:"limit,omitempty"` // 0: the caller's default
+}
+
+// QueryOptions resolves the filter into query options as of now.
+func (f ViewFilter) QueryOptions() (QueryOptions, error) {
+	opts, err := BuildQueryOptions(f.Limit, 0, f.Unread, f.Since, "")
+	if err != nil {
+		return opts, err
+	}
+	opts.Read = f.Read
+	opts.StarredOnly = f.Starred
+	opts.FeedIDs = f.FeedIDs
+	opts.Categories = f.Categories
+	opts.Tags = f.Tags
+	opts.AllTags = f.AllTags
+	opts.Dedupe = f.Dedupe
+	opts.Sort = f.Sort
+	opts.Order = f.Order
+
+	if opts.After, err = viewDate("after", f.After); err != nil {
+		return opts, err
+	}
+	if opts.Before, err = viewDate("before", f.Before); err != nil {
+		return opts, err
+	}
+
+	return opts, opts.Validate()
+}
+
+// viewDate parses a date filter into a Unix timestamp (nil if empty).
+func viewDate(name, value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := ParseDate(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --%s flag: %w", name, err)
+	}
+	unix := t.Unix()
+	return &unix, nil
+}
+
+// SaveView saves a view for the current user, replacing any view of the
+// same name.
+func (s *Store) SaveView(name string, filter ViewFilter) (*View, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("view name is required")
+	}
+	if filter.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit: %d", filter.Limit)
+	}
+	if _, err := filter.QueryOptions(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode view: %w", err)
+	}
+
+	now := time.Now().Unix()
+	_, err = s.db.Exec(`
+		INSERT INTO views (user_id, name, filter, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, name) DO UPDATE SET filter = excluded.filter, updated_at = excluded.updated_at`,
+		s.userID, name, string(data), now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save view: %w", err)
+	}
+	return s.GetView(name)
+}
+
+// GetView returns one of the current user's views by name.
+func (s *Store) GetView(name string) (*View, error) {
+	view, err := scanView(s.db.QueryRow(
+		"SELECT name, filter, created_at, updated_at FROM views WHERE user_id = ? AND name = ?",
+		s.userID, strings.TrimSpace(name),
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("view not found: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+	return view, nil
+}
+
+// GetViews returns the current user's views by name, each with how many
+// entries it matches now.
+func (s *Store) GetViews() ([]*ViewCount, error) {
+	rows, err := s.db.Query("SELECT name, filter, created_at, updated_at FROM views WHERE user_id = ? ORDER BY name", s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	views := []*ViewCount{}
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan view: %w", err)
+		}
+		views = append(views, &ViewCount{View: view})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Counted once the rows are closed rather than while holding them open
+	for _, v := range views {
+		if v.Count, v.Unread, err = s.countView(v.Filter); err != nil {
+			return nil, fmt.Errorf("failed to count view %s: %w", v.Name, err)
+		}
+	}
+	return views, nil
+}
+
+// countView counts the entries a filter matches, and how many are unread.
+func (s *Store) countView(f ViewFilter) (count, unread int, err error) {
+	opts, err := f.QueryOptions()
+	if err != nil {
+		return 0, 0, err
+	}
+	// Counts cover every matching entry, whatever the view's sort and limit
+	opts.Sort, opts.Limit = "", 0
+	if count, err = s.CountEntries(opts); err != nil {
+		return 0, 0, err
+	}
+
+	if read, _ := opts.readState(); read == ReadRead {
+		return count, 0, nil
+	}
+	opts.Read = ReadUnread
+	if unread, err = s.CountEntries(opts); err != nil {
+		return 0, 0, err
+	}
+	return count, unread, nil
+}
+
+// DeleteView removes one of the current user's views.
+func (s *Store) DeleteView(name string) error {
+	result, err := s.db.Exec("DELETE FROM views WHERE user_id = ? AND name = ?", s.userID, strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("view not found: %s", name)
+	}
+	return nil
+}
+
+// scanView scans a row of name, filter, created_at and updated_at.
+func scanView(row rowScanner) (*View, error) {
+	view := &View{}
+	var data string
+	var createdAt, updatedAt int64
+	if err := row.Scan(&view.Name, &data, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(data), &view.Filter); err != nil {
+		return nil, fmt.Errorf("invalid filter in view %s: %w", view.Name, err)
+	}
+	view.CreatedAt = unixToTime(createdAt)
+	view.UpdatedAt = unixToTime(updatedAt)
+	return view, nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newViewStore stores two feeds in different categories, with one entry of
+// the news feed read.
+func newViewStore(t *testing.T) (*Store, []*model.Entry) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+
+	news := &model.Feed{URL: "https://news.example.com/rss", Category: "news"}
+	blog := &model.Feed{URL: "https://blog.example.com/rss", Category: "blogs"}
+	require.NoError(t, s.SaveFeed(news))
+	require.NoError(t, s.SaveFeed(blog))
+
+	now := time.Now()
+	entries := []*model.Entry{
+		{GUID: "n1", Title: "News one", Published: now.Add(-time.Hour)},
+		{GUID: "n2", Title: "News two", Published: now.Add(-2 * time.Hour)},
+		{GUID: "n3", Title: "Old news", Published: now.Add(-30 * 24 * time.Hour)},
+	}
+	_, err = s.SaveEntries(news.ID, entries)
+	require.NoError(t, err)
+	blogEntry := &model.Entry{GUID: "b1", Title: "Blog post", Published: now.Add(-time.Hour)}
+	_, err = s.SaveEntries(blog.ID, []*model.Entry{blogEntry})
+	require.NoError(t, err)
+	require.NoError(t, s.MarkEntryRead(entries[1].ID, true))
+
+	return s, append(entries, blogEntry)
+}
+
+func TestStore_SaveView(t *testing.T) {
+	s, _ := newViewStore(t)
+	defer s.Close()
+
+	view, err := s.SaveView(" news ", ViewFilter{Unread: true, Categories: []string{"news"}, Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "news", view.Name)
+	assert.Equal(t, []string{"news"}, view.Filter.Categories)
+	assert.Equal(t, 5, view.Filter.Limit)
+
+	// Saving again replaces the filter but keeps the creation time
+	updated, err := s.SaveView("news", ViewFilter{Starred: true})
+	require.NoError(t, err)
+	assert.True(t, updated.Filter.Starred)
+	assert.Empty(t, updated.Filter.Categories)
+	assert.Equal(t, view.CreatedAt, updated.CreatedAt)
+
+	_, err = s.SaveView("", ViewFilter{})
+	assert.Error(t, err)
+	_, err = s.SaveView("bad", ViewFilter{Sort: "size"})
+	assert.Error(t, err)
+	_, err = s.SaveView("bad", ViewFilter{Since: "soon"})
+	assert.Error(t, err)
+	_, err = s.GetView("bad")
+	assert.Error(t, err, "Invalid filters are not saved")
+}
+
+func TestStore_GetViews(t *testing.T) {
+	s, _ := newViewStore(t)
+	defer s.Close()
+
+	_, err := s.SaveView("news", ViewFilter{Categories: []string{"news"}})
+	require.NoError(t, err)
+	_, err = s.SaveView("recent", ViewFilter{Since: "7d", Limit: 1})
+	require.NoError(t, err)
+	_, err = s.SaveView("done", ViewFilter{Read: ReadRead})
+	require.NoError(t, err)
+
+	views, err := s.GetViews()
+	require.NoError(t, err)
+	require.Len(t, views, 3)
+
+	assert.Equal(t, "done", views[0].Name)
+	assert.Equal(t, 1, views[0].Count)
+	assert.Equal(t, 0, views[0].Unread)
+	assert.Equal(t, "news", views[1].Name)
+	assert.Equal(t, 3, views[1].Count)
+	assert.Equal(t, 2, views[1].Unread)
+	assert.Equal(t, "recent", views[2].Name)
+	assert.Equal(t, 3, views[2].Count, "Counts ignore the view's limit")
+	assert.Equal(t, "7d", views[2].Filter.Since, "Since stays relative")
+}
+
+func TestViewFilter_QueryOptions(t *testing.T) {
+	s, entries := newViewStore(t)
+	defer s.Close()
+
+	opts, err := ViewFilter{Unread: true, Categories: []string{"news"}, Since: "7d"}.QueryOptions()
+	require.NoError(t, err)
+	got, err := s.GetEntries(opts)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, entries[0].ID, got[0].ID)
+
+	opts, err = ViewFilter{Before: "2000-01-01"}.QueryOptions()
+	require.NoError(t, err)
+	count, err := s.CountEntries(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// Marking by a view reads every matching entry, whatever its limit
+	opts, err = ViewFilter{Categories: []string{"news"}, Limit: 1}.QueryOptions()
+	require.NoError(t, err)
+	opts.Limit = 0
+	marked, err := s.MarkReadWhere(opts, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+
+	unread, err := s.CountEntries(QueryOptions{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread, "Only the blog entry is left unread")
+}
+
+func TestStore_ViewSortedByReadTime(t *testing.T) {
+	s, _ := newViewStore(t)
+	defer s.Close()
+
+	// Sorting by read time lists only read entries, but counts and marking
+	// cover every entry the view's filters match
+	filter := ViewFilter{Categories: []string{"news"}, Sort: SortRead}
+	_, err := s.SaveView("history", filter)
+	require.NoError(t, err)
+
+	views, err := s.GetViews()
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, 3, views[0].Count)
+	assert.Equal(t, 2, views[0].Unread)
+
+	opts, err := filter.QueryOptions()
+	require.NoError(t, err)
+	page, err := s.ListEntries(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+	assert.Len(t, page.Entries, 1)
+
+	marked, err := s.MarkReadWhere(opts, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+}
+
+func TestStore_DeleteView(t *testing.T) {
+	s, _ := newViewStore(t)
+	defer s.Close()
+
+	_, err := s.SaveView("news", ViewFilter{Categories: []string{"news"}})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteView("news"))
+	_, err = s.GetView("news")
+	assert.Error(t, err)
+	assert.Error(t, s.DeleteView("news"))
+}
+
+func TestStore_ViewsPerUser(t *testing.T) {
+	s, _ := newViewStore(t)
+	defer s.Close()
+
+	_, err := s.SaveView("news", ViewFilter{Categories: []string{"news"}})
+	require.NoError(t, err)
+
+	addUsers(t, s, "alice")
+	_, err = s.SetUser("alice")
+	require.NoError(t, err)
+	_, err = s.GetView("news")
+	assert.Error(t, err, "Views belong to the user who saved them")
+	views, err := s.GetViews()
+	require.NoError(t, err)
+	assert.Empty(t, views)
+
+	// The same name is free for each user
+	_, err = s.SaveView("news", ViewFilter{Unread: true})
+	require.NoError(t, err)
+	view, err := s.GetView("news")
+	require.NoError(t, err)
+	assert.True(t, view.Filter.Unread)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+
+	"github.com/robertmeta/feed-cli/model"
+)
+
+// writeRequest is one feed's fetch result queued for the writer goroutine:
+// either its entries or, when fetchErr is set, a failed fetch.
+type writeRequest struct {
+	ctx      context.Context
+	feed     *model.Feed
+	entries  []*model.Entry
+	fetchErr error
+	result   chan writeResult
+}
+
+type writeResult struct {
+	inserted int
+	err      error
+}
+
+// EntryWriter saves fetch results for many feeds at once; Storage.NewWriter
+// returns one. Write and WriteError are safe for concurrent use.
+type EntryWriter interface {
+	Write(ctx context.Context, f *model.Feed, entries []*model.Entry) (int, error)
+	WriteError(ctx context.Context, f *model.Feed, fetchErr error) error
+	Close()
+}
+
+var _ EntryWriter = (*Writer)(nil)
+
+// Writer funnels entry inserts from many goroutines through a single writer
+// goroutine, so SQLite only ever sees one writer. Each feed's batch is saved
+// with SaveFetch in its own transaction.
+type Writer struct {
+	store    *Store
+	requests chan writeRequest
+	done     chan struct{}
+}
+
+// NewWriter starts a writer goroutine for the store. Call Close when done.
+func (s *Store) NewWriter() EntryWriter {
+	w := &Writer{
+		store:    s,
+		requests: make(chan writeRequest),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for req := range w.requests {
+		if req.fetchErr != nil {
+			err := w.store.RecordFetchErrorContext(req.ctx, req.feed.ID, req.fetchErr)
+			req.result <- writeResult{err: err}
+			continue
+		}
+		inserted, err := w.store.SaveFetchContext(req.ctx, req.feed, req.entries)
+		req.result <- writeResult{inserted: inserted, err: err}
+	}
+}
+
+// Write queues a feed's entries and fetch state and waits until they are
+// committed. It is safe for concurrent use and returns the number of new
+// entries. If ctx is done before the batch is picked up, nothing is written;
+// once the transaction has started it follows SaveFetchContext semantics.
+// Write must not be called after Close.
+func (w *Writer) Write(ctx context.Context, f *model.Feed, entries []*model.Entry) (int, error) {
+	return w.send(ctx, writeRequest{feed: f, entries: entries})
+}
+
+// WriteError records a failed fetch of f (see RecordFetchError) through the
+// writer goroutine. Like Write, it must not be called after Close.
+func (w *Writer) WriteError(ctx context.Context, f *model.Feed, fetchErr error) error {
+	_, err := w.send(ctx, writeRequest{feed: f, fetchErr: fetchErr})
+	return err
+}
+
+func (w *Writer) send(ctx context.Context, req writeRequest) (int, error) {
+	req.ctx = ctx
+	req.result = make(chan writeResult, 1)
+	select {
+	case w.requests <- req:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	r := <-req.result
+	return r.inserted, r.err
+}
+
+// Close stops the writer goroutine once all pending writes have completed.
+func (w *Writer) Close() {
+	close(w.requests)
+	<-w.done
+}
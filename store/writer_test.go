@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robertmeta/feed-cli/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeEntries builds n entries with GUIDs prefix-0 .. prefix-(n-1).
+func makeEntries(prefix string, n int) []*model.Entry {
+	entries := make([]*model.Entry, n)
+	for i := range entries {
+		entries[i] = &model.Entry{
+			GUID:      fmt.Sprintf("%s-%d", prefix, i),
+			Title:     fmt.Sprintf("Entry %d", i),
+			Link:      fmt.Sprintf("https://example.com/%s/%d", prefix, i),
+			Content:   "<p>content</p>",
+			Published: time.Now().Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	return entries
+}
+
+func TestStore_SaveEntries(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	entries := makeEntries("a", 10)
+	inserted, err := s.SaveEntries(feed.ID, entries)
+	require.NoError(t, err)
+	assert.Equal(t, 10, inserted)
+	for _, e := range entries {
+		assert.NotZero(t, e.ID, "New entries should get an ID")
+		assert.Equal(t, feed.ID, e.FeedID)
+	}
+
+	// Re-saving the same GUIDs plus a few new ones only inserts the new ones
+	inserted, err = s.SaveEntries(feed.ID, makeEntries("a", 15))
+	require.NoError(t, err)
+	assert.Equal(t, 5, inserted)
+
+	all, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all, 15)
+}
+
+func TestStore_SaveEntries_ReportsErrors(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	s.Close()
+
+	// Errors must surface instead of being counted as duplicates
+	_, err = s.SaveEntries(1, makeEntries("a", 1))
+	assert.Error(t, err)
+}
+
+func TestWriter_ConcurrentWrites(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	const feeds = 20
+	var feedList []*model.Feed
+	for i := 0; i < feeds; i++ {
+		f := &model.Feed{URL: fmt.Sprintf("https://example.com/%d", i)}
+		require.NoError(t, s.SaveFeed(f))
+		feedList = append(feedList, f)
+	}
+
+	w := s.NewWriter()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for _, f := range feedList {
+		wg.Add(1)
+		go func(f *model.Feed) {
+			defer wg.Done()
+			n, err := w.Write(context.Background(), f, makeEntries("e", 25))
+			assert.NoError(t, err)
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}(f)
+	}
+	wg.Wait()
+	w.Close()
+
+	assert.Equal(t, feeds*25, total)
+	all, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all, feeds*25)
+}
+
+// BenchmarkWriter_1000Feeds ingests 1,000 feeds x 100 entries through the
+// single writer with 50 concurrent producers, mirroring `update`.
+func BenchmarkWriter_1000Feeds(b *testing.B) {
+	const feeds = 1000
+	const perFeed = 100
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s, err := New(filepath.Join(b.TempDir(), fmt.Sprintf("bench-%d.db", i)))
+		require.NoError(b, err)
+
+		var feedList []*model.Feed
+		for j := 0; j < feeds; j++ {
+			f := &model.Feed{URL: fmt.Sprintf("https://example.com/%d", j)}
+			require.NoError(b, s.SaveFeed(f))
+			feedList = append(feedList, f)
+		}
+		batches := make([][]*model.Entry, feeds)
+		for j := range batches {
+			batches[j] = makeEntries(fmt.Sprintf("f%d", j), perFeed)
+		}
+		b.StartTimer()
+
+		w := s.NewWriter()
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, 50)
+		for j, f := range feedList {
+			wg.Add(1)
+			go func(f *model.Feed, entries []*model.Entry) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if _, err := w.Write(context.Background(), f, entries); err != nil {
+					b.Error(err)
+				}
+			}(f, batches[j])
+		}
+		wg.Wait()
+		w.Close()
+
+		b.StopTimer()
+		s.Close()
+		b.StartTimer()
+	}
+}
+
+// TestInsertEntries_RowsWritten keeps the cost BenchmarkWriter_1000Feeds
+// measures from creeping back up: a new entry writes its entries row, its
+// entry_content row and what indexing it once costs FTS5, counting writes
+// made by triggers.
+func TestInsertEntries_RowsWritten(t *testing.T) {
+	skipOnPostgres(t)
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	changes := func() int {
+		var n int
+		require.NoError(t, tx.QueryRow("SELECT total_changes()").Scan(&n))
+		return n
+	}
+
+	entries := makeEntries("a", 50)
+	before := changes()
+	inserted, err := insertEntries(ctx, tx, s.userID, feed.ID, entries)
+	require.NoError(t, err)
+	written := changes() - before
+	require.Equal(t, 50, inserted)
+
+	// FTS5 writes several shadow-table rows per document; index the same
+	// documents once each into a scratch table to see how many
+	_, err = tx.Exec("CREATE VIRTUAL TABLE temp.fts_reference USING fts5(title, content, author, tokenize = 'porter unicode61')")
+	require.NoError(t, err)
+	before = changes()
+	for _, e := range entries {
+		_, err = tx.Exec("INSERT INTO fts_reference (rowid, title, content, author) SELECT rowid, title, content, author FROM entries_fts WHERE rowid = ?", e.ID)
+		require.NoError(t, err)
+	}
+	indexed := changes() - before
+
+	assert.Equal(t, 2*len(entries)+indexed, written)
+}
+
+func TestStore_SaveFetch(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	fetchedAt := time.Unix(1700000000, 0)
+	feed.ETag = `"abc"`
+	feed.LastModified = "Tue, 14 Nov 2023 22:13:20 GMT"
+	feed.LastUpdated = &fetchedAt
+
+	inserted, err := s.SaveFetch(feed, makeEntries("a", 3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, inserted)
+
+	got, err := s.GetFeed(feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `"abc"`, got.ETag)
+	assert.Equal(t, feed.LastModified, got.LastModified)
+	require.NotNil(t, got.LastUpdated)
+	assert.True(t, fetchedAt.Equal(*got.LastUpdated))
+
+	// A not-modified fetch has no entries but still records the fetch time
+	later := fetchedAt.Add(time.Hour)
+	feed.LastUpdated = &later
+	inserted, err = s.SaveFetch(feed, nil)
+	require.NoError(t, err)
+	assert.Zero(t, inserted)
+
+	got, err = s.GetFeed(feed.ID)
+	require.NoError(t, err)
+	assert.True(t, later.Equal(*got.LastUpdated))
+}
+
+func TestStore_SaveEntriesContext_Canceled(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.SaveEntriesContext(ctx, feed.ID, makeEntries("a", 5))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	all, err := s.GetEntries(QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, all, "Nothing should be written when the context is canceled")
+}
+
+func TestWriter_Write_Canceled(t *testing.T) {
+	s, err := openTestStore(t)
+	require.NoError(t, err)
+	defer s.Close()
+
+	feed := &model.Feed{URL: "https://example.com/rss", Title: "Test"}
+	require.NoError(t, s.SaveFeed(feed))
+
+	w := s.NewWriter()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = w.Write(ctx, feed, makeEntries("a", 5))
+	assert.ErrorIs(t, err, context.Canceled)
+}